@@ -380,7 +380,8 @@ $(COV_PATH_INTEGRATION): $(SOURCES) $(GOCOVMERGE_BIN)
 generate-mocks: deps $(MINIMOCK_BIN) ## Generate Minimock sources. Only necessary after clean or if changes occurred in interfaces.
 	@echo "Generating mocks..."
 	@-mkdir -p test/generated/token
-	@$(MINIMOCK_BIN) -i github.com/fabric8-services/fabric8-common/auth.ManagerConfiguration -o ./test/generated/token/ -s ".go"
+	# minimock loads the interfaces with the GOPATH loader, from the vendor directory
+	@GO111MODULE=off $(MINIMOCK_BIN) -i github.com/fabric8-services/fabric8-common/auth.ManagerConfiguration -o ./test/generated/token/ -s ".go"
 
 #-------------------------------------------------------------------------------
 # Additional tools to build
@@ -390,11 +391,11 @@ $(GOCOV_BIN): prebuild-check
 	@cd $(VENDOR_DIR)/github.com/axw/gocov/gocov/ && go build
 
 $(GOCOVMERGE_BIN): prebuild-check
-	@cd $(VENDOR_DIR)/github.com/wadey/gocovmerge && go build
+	@go build -o $(GOCOVMERGE_BIN) github.com/wadey/gocovmerge
 
 $(MINIMOCK_BIN):
 	@echo "building the minimock binary..."
-	@cd $(VENDOR_DIR)/github.com/gojuno/minimock/cmd/minimock && go build -v minimock.go
+	@go build -v -o $(MINIMOCK_BIN) github.com/gojuno/minimock/cmd/minimock
 
 #-------------------------------------------------------------------------------
 # Clean targets
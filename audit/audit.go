@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/auth"
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/requestid"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	errs "github.com/pkg/errors"
+)
+
+// Outcome the outcome of an audited action
+type Outcome string
+
+const (
+	// OutcomeSuccess the action succeeded
+	OutcomeSuccess Outcome = "success"
+	// OutcomeFailure the action failed
+	OutcomeFailure Outcome = "failure"
+	// OutcomeDenied the action was not authorized
+	OutcomeDenied Outcome = "denied"
+)
+
+// Event a security-relevant event
+type Event struct {
+	Time time.Time `json:"time"`
+	// IdentityID the `sub` claim of the token in the request context, if any
+	IdentityID string `json:"identity_id,omitempty"`
+	// ServiceAccount the `service_accountname` claim of the token in the request context, if any
+	ServiceAccount string                 `json:"service_account,omitempty"`
+	Action         string                 `json:"action"`
+	Resource       string                 `json:"resource"`
+	Outcome        Outcome                `json:"outcome"`
+	RequestID      string                 `json:"request_id,omitempty"`
+	Details        map[string]interface{} `json:"details,omitempty"`
+}
+
+// NewEvent returns a new event for the given action on the given resource, with the identity,
+// service account and request ID found in the given context
+func NewEvent(ctx context.Context, action, resource string, outcome Outcome) Event {
+	e := Event{
+		Time:     time.Now().UTC(),
+		Action:   action,
+		Resource: resource,
+		Outcome:  outcome,
+	}
+	if ctx == nil {
+		return e
+	}
+	e.RequestID = requestid.FromContext(ctx)
+	if token := goajwt.ContextJWT(ctx); token != nil {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if sub, ok := claims["sub"].(string); ok {
+				e.IdentityID = sub
+			}
+		}
+	}
+	if name, ok := auth.ExtractServiceAccountName(ctx); ok {
+		e.ServiceAccount = name
+	}
+	return e
+}
+
+// Sink the interface to implement to store the audit events. The sinks only append the events,
+// they never modify nor remove the events which were already stored.
+type Sink interface {
+	Write(ctx context.Context, e Event) error
+}
+
+var (
+	sinkMu sync.RWMutex
+	sink   Sink = NewWriterSink(nil)
+)
+
+// SetSink sets the sink of the audit events (by default, the events are written as JSON lines to os.Stdout)
+func SetSink(s Sink) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	sink = s
+}
+
+// CurrentSink returns the sink of the audit events
+func CurrentSink() Sink {
+	sinkMu.RLock()
+	defer sinkMu.RUnlock()
+	return sink
+}
+
+// Record writes an event for the given action on the given resource to the current sink.
+// The details are optional.
+func Record(ctx context.Context, action, resource string, outcome Outcome, details map[string]interface{}) error {
+	e := NewEvent(ctx, action, resource, outcome)
+	e.Details = details
+	return write(ctx, CurrentSink(), e)
+}
+
+// write writes the event to the given sink, masking the sensitive data in its details.
+// A failure is also logged, since the audit events must not be lost silently.
+func write(ctx context.Context, s Sink, e Event) error {
+	if r := log.CurrentRedactor(); r != nil && e.Details != nil {
+		e.Details = r.RedactFields(e.Details)
+	}
+	if err := s.Write(ctx, e); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":             err,
+			"action":          e.Action,
+			"resource":        e.Resource,
+			"outcome":         e.Outcome,
+			"identity_id":     e.IdentityID,
+			"service_account": e.ServiceAccount,
+		}, "failed to write the audit event")
+		return errs.Wrap(err, "failed to write the audit event")
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/audit"
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySink struct {
+	mu     sync.Mutex
+	events []audit.Event
+	err    error
+}
+
+func (s *memorySink) Write(ctx context.Context, e audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func contextWithToken(claims jwt.MapClaims) context.Context {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	return goajwt.WithJWT(requestid.NewContext(context.Background(), "req-1"), token)
+}
+
+func TestNewEvent(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("user", func(t *testing.T) {
+		// given
+		ctx := contextWithToken(jwt.MapClaims{"sub": "8ee6cd2b-3b5b-4f8a-a0a5-1bfa8ed6a5c2"})
+		// when
+		e := audit.NewEvent(ctx, "space.delete", "/api/spaces/foo", audit.OutcomeSuccess)
+		// then
+		assert.Equal(t, "8ee6cd2b-3b5b-4f8a-a0a5-1bfa8ed6a5c2", e.IdentityID)
+		assert.Empty(t, e.ServiceAccount)
+		assert.Equal(t, "req-1", e.RequestID)
+		assert.Equal(t, "space.delete", e.Action)
+		assert.Equal(t, "/api/spaces/foo", e.Resource)
+		assert.Equal(t, audit.OutcomeSuccess, e.Outcome)
+		assert.False(t, e.Time.IsZero())
+	})
+
+	t.Run("service account", func(t *testing.T) {
+		// given
+		ctx := contextWithToken(jwt.MapClaims{"sub": "foo", "service_accountname": "fabric8-tenant"})
+		// when
+		e := audit.NewEvent(ctx, "tenant.clean", "/api/tenants/foo", audit.OutcomeFailure)
+		// then
+		assert.Equal(t, "fabric8-tenant", e.ServiceAccount)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		// when
+		e := audit.NewEvent(context.Background(), "user.create", "/api/users", audit.OutcomeDenied)
+		// then
+		assert.Empty(t, e.IdentityID)
+		assert.Empty(t, e.RequestID)
+	})
+}
+
+func TestWriterSink(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("writer", func(t *testing.T) {
+		// given
+		buf := &bytes.Buffer{}
+		s := audit.NewWriterSink(buf)
+		e := audit.NewEvent(context.Background(), "space.delete", "/api/spaces/foo", audit.OutcomeSuccess)
+		// when
+		err := s.Write(context.Background(), e)
+		// then
+		require.NoError(t, err)
+		fields := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+		assert.Equal(t, true, fields["audit"])
+		assert.Equal(t, "space.delete", fields["action"])
+		assert.Equal(t, "success", fields["outcome"])
+	})
+
+	t.Run("file", func(t *testing.T) {
+		// given
+		dir, err := ioutil.TempDir("", "audit")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "audit.log")
+		for _, action := range []string{"first", "second"} {
+			s, closer, err := audit.NewFileSink(path)
+			require.NoError(t, err)
+			// when
+			err = s.Write(context.Background(), audit.NewEvent(context.Background(), action, "/", audit.OutcomeSuccess))
+			require.NoError(t, err)
+			require.NoError(t, closer.Close())
+		}
+		// then the events are appended
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+		require.Len(t, lines, 2)
+		assert.Contains(t, string(lines[0]), `"action":"first"`)
+		assert.Contains(t, string(lines[1]), `"action":"second"`)
+	})
+}
+
+// the tests below replace the global sink, so they must not run in parallel
+
+func TestRecord(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	defer audit.SetSink(audit.CurrentSink())
+
+	t.Run("ok", func(t *testing.T) {
+		// given
+		s := &memorySink{}
+		audit.SetSink(s)
+		ctx := contextWithToken(jwt.MapClaims{"sub": "foo"})
+		// when
+		err := audit.Record(ctx, "token.exchange", "/api/token", audit.OutcomeSuccess, map[string]interface{}{
+			"client_id":     "bar",
+			"client_secret": "secret",
+		})
+		// then
+		require.NoError(t, err)
+		require.Len(t, s.events, 1)
+		assert.Equal(t, "foo", s.events[0].IdentityID)
+		assert.Equal(t, "bar", s.events[0].Details["client_id"])
+		assert.Equal(t, "*****", s.events[0].Details["client_secret"])
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		audit.SetSink(&memorySink{err: errors.New("disk full")})
+		// when
+		err := audit.Record(context.Background(), "token.exchange", "/api/token", audit.OutcomeSuccess, nil)
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "disk full")
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	service := goa.New("test")
+	service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	ctrl := service.NewController("space")
+	testCases := []struct {
+		name     string
+		method   string
+		status   int
+		err      error
+		recorded bool
+		outcome  audit.Outcome
+	}{
+		{name: "created", method: http.MethodPost, status: http.StatusCreated, recorded: true, outcome: audit.OutcomeSuccess},
+		{name: "forbidden", method: http.MethodDelete, status: http.StatusForbidden, recorded: true, outcome: audit.OutcomeDenied},
+		{name: "conflict", method: http.MethodPatch, status: http.StatusConflict, recorded: true, outcome: audit.OutcomeFailure},
+		{name: "unhandled error", method: http.MethodPut, err: errors.New("failure"), recorded: true, outcome: audit.OutcomeFailure},
+		{name: "read-only", method: http.MethodGet, status: http.StatusOK, recorded: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			s := &memorySink{}
+			req, _ := http.NewRequest(tc.method, "/api/spaces/foo", nil)
+			rw := httptest.NewRecorder()
+			ctx := goa.NewContext(ctrl.Context, rw, req, nil)
+			ctx = goa.WithAction(ctx, "update")
+			h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				if tc.err != nil {
+					return tc.err
+				}
+				return service.Send(ctx, tc.status, "")
+			}
+			// when
+			err := audit.Middleware(audit.WithSink(s))(h)(ctx, rw, req)
+			// then
+			assert.Equal(t, tc.err, err)
+			if !tc.recorded {
+				assert.Empty(t, s.events)
+				return
+			}
+			require.Len(t, s.events, 1)
+			assert.Equal(t, "space.update", s.events[0].Action)
+			assert.Equal(t, "/api/spaces/foo", s.events[0].Resource)
+			assert.Equal(t, tc.outcome, s.events[0].Outcome)
+			assert.Equal(t, tc.method, s.events[0].Details["method"])
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package audit records the security-relevant events, such as the state-changing requests, separately
+// from the diagnostic logs: each event says who (the identity and the service account from the token in
+// the request context) did what (the action, the resource and the outcome) during which request.
+// The events are written to an append-only Sink, such as a JSON lines file or a Postgres table.
+package audit
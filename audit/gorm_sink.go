@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// TableName the name of the table in which the Postgres sink stores the audit events
+const TableName = "audit_events"
+
+// CreateTableSQL the SQL statements to include in the migrations of the services which use the Postgres sink.
+// The rules make the table append-only: the updates and deletions are silently ignored.
+const CreateTableSQL = `CREATE TABLE IF NOT EXISTS audit_events (
+    id uuid PRIMARY KEY,
+    time timestamp with time zone NOT NULL,
+    identity_id text,
+    service_account text,
+    action text NOT NULL,
+    resource text NOT NULL,
+    outcome text NOT NULL,
+    request_id text,
+    details jsonb
+);
+CREATE INDEX IF NOT EXISTS audit_events_time_idx ON audit_events (time);
+CREATE INDEX IF NOT EXISTS audit_events_identity_id_idx ON audit_events (identity_id);
+CREATE OR REPLACE RULE audit_events_no_update AS ON UPDATE TO audit_events DO INSTEAD NOTHING;
+CREATE OR REPLACE RULE audit_events_no_delete AS ON DELETE TO audit_events DO INSTEAD NOTHING;`
+
+// eventRecord the row of an audit event in the Postgres table
+type eventRecord struct {
+	ID             uuid.UUID `sql:"type:uuid" gorm:"primary_key;column:id"`
+	Time           time.Time `gorm:"column:time"`
+	IdentityID     string    `gorm:"column:identity_id"`
+	ServiceAccount string    `gorm:"column:service_account"`
+	Action         string    `gorm:"column:action"`
+	Resource       string    `gorm:"column:resource"`
+	Outcome        string    `gorm:"column:outcome"`
+	RequestID      string    `gorm:"column:request_id"`
+	Details        *string   `sql:"type:jsonb" gorm:"column:details"`
+}
+
+// TableName implements gorm.tabler
+func (r eventRecord) TableName() string {
+	return TableName
+}
+
+// NewGormSink returns a sink which inserts the events in the `audit_events` table of the given
+// Postgres database (see CreateTableSQL). The events are inserted in the transaction stored in the
+// context of the event with WithTransaction, if any, so they are only stored if the audited change
+// is committed. Otherwise, they are inserted with the given gorm DB.
+func NewGormSink(db *gorm.DB) Sink {
+	return &gormSink{db: db}
+}
+
+type transactionKey struct{}
+
+// WithTransaction returns a copy of the given context in which the given transaction is stored,
+// so that the audit events recorded in this context are inserted in this transaction by the
+// Postgres sink (see NewGormSink)
+func WithTransaction(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, transactionKey{}, tx)
+}
+
+// transactionFromContext returns the transaction stored in the given context, or nil if there is none
+func transactionFromContext(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return nil
+	}
+	tx, _ := ctx.Value(transactionKey{}).(*gorm.DB)
+	return tx
+}
+
+type gormSink struct {
+	db *gorm.DB
+}
+
+func (s *gormSink) Write(ctx context.Context, e Event) error {
+	r := eventRecord{
+		ID:             uuid.NewV4(),
+		Time:           e.Time,
+		IdentityID:     e.IdentityID,
+		ServiceAccount: e.ServiceAccount,
+		Action:         e.Action,
+		Resource:       e.Resource,
+		Outcome:        string(e.Outcome),
+		RequestID:      e.RequestID,
+	}
+	if len(e.Details) > 0 {
+		js, err := json.Marshal(e.Details)
+		if err != nil {
+			return errs.Wrap(err, "failed to marshal the audit event details")
+		}
+		details := string(js)
+		r.Details = &details
+	}
+	db := s.db
+	if tx := transactionFromContext(ctx); tx != nil {
+		db = tx
+	}
+	if err := db.Create(&r).Error; err != nil {
+		return errs.Wrap(err, "failed to insert the audit event")
+	}
+	return nil
+}
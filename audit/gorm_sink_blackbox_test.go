@@ -0,0 +1,97 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/audit"
+	"github.com/fabric8-services/fabric8-common/internal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type GormSinkTestSuite struct {
+	internal.DBTestSuite
+}
+
+func TestGormSink(t *testing.T) {
+	suite.Run(t, &GormSinkTestSuite{internal.NewDBTestSuiteSuite()})
+}
+
+// SetupSuite creates the table of the audit events, which is dropped along with the test database
+func (s *GormSinkTestSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	require.NoError(s.T(), s.DB.Exec(audit.CreateTableSQL).Error)
+}
+
+// count returns the number of audit events with the given action
+func (s *GormSinkTestSuite) count(action string) int {
+	var count int
+	require.NoError(s.T(), s.DB.Table(audit.TableName).Where("action = ?", action).Count(&count).Error)
+	return count
+}
+
+func (s *GormSinkTestSuite) TestWrite() {
+	// given
+	sink := audit.NewGormSink(s.DB)
+	e := audit.NewEvent(contextWithToken(nil), "space.delete", "space/1", audit.OutcomeSuccess)
+	e.Details = map[string]interface{}{"name": "foo"}
+	// when
+	err := sink.Write(context.Background(), e)
+	// then
+	require.NoError(s.T(), err)
+	var row struct {
+		Resource  string
+		Outcome   string
+		RequestID string
+		Details   string
+	}
+	require.NoError(s.T(), s.DB.Raw("SELECT resource, outcome, request_id, details FROM "+audit.TableName+" WHERE action = ?", "space.delete").Scan(&row).Error)
+	assert.Equal(s.T(), "space/1", row.Resource)
+	assert.Equal(s.T(), string(audit.OutcomeSuccess), row.Outcome)
+	assert.Equal(s.T(), "req-1", row.RequestID)
+	assert.JSONEq(s.T(), `{"name":"foo"}`, row.Details)
+
+	s.T().Run("append-only", func(t *testing.T) {
+		// when
+		err := s.DB.Exec("UPDATE "+audit.TableName+" SET outcome = 'failure' WHERE action = ?", "space.delete").Error
+		require.NoError(t, err)
+		err = s.DB.Exec("DELETE FROM "+audit.TableName+" WHERE action = ?", "space.delete").Error
+		require.NoError(t, err)
+		// then the event is unchanged
+		var outcome string
+		require.NoError(t, s.DB.Raw("SELECT outcome FROM "+audit.TableName+" WHERE action = ?", "space.delete").Row().Scan(&outcome))
+		assert.Equal(t, string(audit.OutcomeSuccess), outcome)
+	})
+}
+
+func (s *GormSinkTestSuite) TestWriteInTransaction() {
+	// given
+	sink := audit.NewGormSink(s.DB)
+
+	s.T().Run("rolled back", func(t *testing.T) {
+		// given
+		tx := s.DB.Begin()
+		ctx := audit.WithTransaction(context.Background(), tx)
+		// when
+		err := sink.Write(ctx, audit.NewEvent(ctx, "space.rollback", "space/1", audit.OutcomeSuccess))
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback().Error)
+		// then
+		assert.Equal(t, 0, s.count("space.rollback"))
+	})
+
+	s.T().Run("committed", func(t *testing.T) {
+		// given
+		tx := s.DB.Begin()
+		ctx := audit.WithTransaction(context.Background(), tx)
+		// when
+		err := sink.Write(ctx, audit.NewEvent(ctx, "space.commit", "space/1", audit.OutcomeSuccess))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit().Error)
+		// then
+		assert.Equal(t, 1, s.count("space.commit"))
+	})
+}
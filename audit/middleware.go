@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-common/httpsupport/responsewriter"
+
+	"github.com/goadesign/goa"
+)
+
+// MiddlewareOption an option to configure the audit middleware
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	sink    Sink
+	methods map[string]bool
+}
+
+// WithSink writes the events of the middleware to the given sink instead of the current sink (see SetSink)
+func WithSink(s Sink) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.sink = s
+	}
+}
+
+// WithMethods records the requests with the given methods instead of the state-changing methods
+// (POST, PUT, PATCH and DELETE)
+func WithMethods(methods ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.methods = map[string]bool{}
+		for _, m := range methods {
+			c.methods[m] = true
+		}
+	}
+}
+
+// Middleware returns a goa middleware which records an audit event for each state-changing request.
+// The action is the controller and action handling the request (e.g. "space.update"), the resource is
+// the path of the request, and the outcome depends on the response status: "denied" for 401 and 403,
+// "failure" for the other errors, "success" otherwise. The method and the status are in the details.
+// It should be mounted after the request ID and JWT middlewares, so the events include the request and
+// identity IDs.
+func Middleware(options ...MiddlewareOption) goa.Middleware {
+	c := &middlewareConfig{
+		methods: map[string]bool{
+			http.MethodPost:   true,
+			http.MethodPut:    true,
+			http.MethodPatch:  true,
+			http.MethodDelete: true,
+		},
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if !c.methods[req.Method] {
+				return h(ctx, rw, req)
+			}
+			err := h(ctx, rw, req)
+			status := responsewriter.GoaStatus(ctx, err)
+			e := NewEvent(ctx, fmt.Sprintf("%s.%s", goa.ContextController(ctx), goa.ContextAction(ctx)), req.URL.Path, outcome(status))
+			e.Details = map[string]interface{}{
+				"method": req.Method,
+				"status": status,
+			}
+			s := c.sink
+			if s == nil {
+				s = CurrentSink()
+			}
+			// a failure to write the event is logged, but does not change the response
+			write(ctx, s, e)
+			return err
+		}
+	}
+}
+
+func outcome(status int) Outcome {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	case status >= http.StatusBadRequest:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// NewWriterSink returns a sink which writes each event as a JSON object on a single line to the given
+// writer (or os.Stdout if nil). Each line has an `"audit":true` member, to tell the audit events
+// from the diagnostic logs if both are written to the same output.
+func NewWriterSink(w io.Writer) Sink {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &writerSink{w: w}
+}
+
+// NewFileSink returns a sink which appends the events as JSON lines to the file at the given path (see
+// NewWriterSink). The file is created if needed, and it is only readable and writable by its owner.
+func NewFileSink(path string) (Sink, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewWriterSink(f), f, nil
+}
+
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+type writerEvent struct {
+	Audit bool `json:"audit"`
+	Event
+}
+
+func (s *writerSink) Write(ctx context.Context, e Event) error {
+	js, err := json.Marshal(writerEvent{Audit: true, Event: e})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(js, '\n'))
+	return err
+}
@@ -33,7 +33,8 @@ type JSONKeys struct {
 
 // FetchKeys fetches public JSON WEB Keys from a remote service
 func FetchKeys(keysEndpointURL string, options ...httpsupport.HTTPClientOption) ([]*PublicKey, error) {
-	httpClient := http.DefaultClient
+	// do not let the options modify the default client
+	httpClient := &http.Client{}
 	for _, opt := range options {
 		opt(httpClient)
 	}
@@ -55,7 +55,7 @@ func (a *serviceImpl) RequireScope(ctx context.Context, resourceID, requiredScop
 
 // createClient creates a new client to be used to call Auth service
 func (a *serviceImpl) createClient(ctx context.Context) *authclient.Client {
-	c := authclient.New(goaclient.HTTPClientDoer(http.DefaultClient))
+	c := authclient.New(goasupport.NewTracingDoer(goaclient.HTTPClientDoer(http.DefaultClient)))
 	c.Host = a.authURL.Host
 	c.Scheme = a.authURL.Scheme
 	c.SetJWTSigner(goasupport.NewForwardSigner(ctx))
@@ -327,7 +327,8 @@ func ServiceAccountToken(ctx context.Context, config AuthServiceConfiguration, c
 		return "", err
 	}
 
-	httpClient := http.DefaultClient
+	// do not let the options modify the default client
+	httpClient := &http.Client{}
 	for _, opt := range options {
 		opt(httpClient)
 	}
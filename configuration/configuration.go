@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	errs "github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -25,14 +26,26 @@ func (c *Registry) String() string {
 }
 
 const (
-	varLogLevel             = "log.level"
-	varDeveloperModeEnabled = "developer.mode.enabled"
-	varAuthURL              = "auth.url"
-	varEnvironment          = "environment"
-	varLogJSON              = "log.json"
-	varHTTPAddress          = "http.address"
-	varMetricsHTTPAddress   = "metrics.http.address"
-	varDiagnoseHTTPAddress  = "diagnose.http.address"
+	varLogLevel              = "log.level"
+	varDeveloperModeEnabled  = "developer.mode.enabled"
+	varAuthURL               = "auth.url"
+	varEnvironment           = "environment"
+	varLogJSON               = "log.json"
+	varHTTPAddress           = "http.address"
+	varMetricsHTTPAddress    = "metrics.http.address"
+	varDiagnoseHTTPAddress   = "diagnose.http.address"
+	varLogLevelResetTimeout  = "log.runtime.level.timeout"
+	varLogSamplingInterval   = "log.sampling.interval"
+	varLogSamplingFirst      = "log.sampling.first"
+	varLogSamplingThereafter = "log.sampling.thereafter"
+	varLogOutput             = "log.output"
+	varLogFilePath           = "log.file.path"
+	varLogFileLevel          = "log.file.level"
+	varLogFileJSON           = "log.file.json"
+	varLogFileMaxSize        = "log.file.max.size"
+	varLogFileMaxBackups     = "log.file.max.backups"
+	varLogFileMaxAge         = "log.file.max.age"
+	varLogFileRotation       = "log.file.rotation.interval"
 
 	defaultLogLevel = "info"
 
@@ -117,6 +130,16 @@ func (c *Registry) setConfigDefaults() {
 	c.v.SetDefault(varDeveloperModeEnabled, false)
 	c.v.SetDefault(varHTTPAddress, "0.0.0.0:8080")
 	c.v.SetDefault(varMetricsHTTPAddress, "0.0.0.0:8080")
+	c.v.SetDefault(varLogLevelResetTimeout, "10m")
+	c.v.SetDefault(varLogSamplingInterval, "1s")
+	c.v.SetDefault(varLogSamplingFirst, 100)
+	c.v.SetDefault(varLogSamplingThereafter, 100)
+	c.v.SetDefault(varLogOutput, "stdout")
+	c.v.SetDefault(varLogFileJSON, true)
+	c.v.SetDefault(varLogFileMaxSize, 100)
+	c.v.SetDefault(varLogFileMaxBackups, 7)
+	c.v.SetDefault(varLogFileMaxAge, "168h")
+	c.v.SetDefault(varLogFileRotation, "24h")
 }
 
 // GetLogLevel returns the loggging level (as set via config file or environment variable)
@@ -124,6 +147,81 @@ func (c *Registry) GetLogLevel() string {
 	return c.v.GetString(varLogLevel)
 }
 
+// GetLogLevelResetTimeout returns the duration after which the log levels changed at runtime
+// (via the log level handler or signals) are reverted to their initial value (default: 10 minutes)
+func (c *Registry) GetLogLevelResetTimeout() time.Duration {
+	return c.v.GetDuration(varLogLevelResetTimeout)
+}
+
+// GetLogSamplingInterval returns the interval during which the number of log entries with the same
+// message is limited (default: 1 second). The log sampling is disabled if the interval is 0.
+func (c *Registry) GetLogSamplingInterval() time.Duration {
+	return c.v.GetDuration(varLogSamplingInterval)
+}
+
+// GetLogSamplingFirst returns the number of log entries with the same message which are all logged
+// during each sampling interval (default: 100)
+func (c *Registry) GetLogSamplingFirst() int {
+	return c.v.GetInt(varLogSamplingFirst)
+}
+
+// GetLogSamplingThereafter returns the sampling rate of the log entries with the same message once the
+// first entries were logged during the sampling interval, i.e., 1 out of N entries is logged (default: 100).
+// All the remaining entries are suppressed during the sampling interval if the rate is 0.
+func (c *Registry) GetLogSamplingThereafter() int {
+	return c.v.GetInt(varLogSamplingThereafter)
+}
+
+// GetLogOutput returns the console output of the logs: "stdout" (the default), "stderr" or "none".
+// The console logs are written at the level and in the format returned by GetLogLevel and IsLogJSON.
+func (c *Registry) GetLogOutput() string {
+	return c.v.GetString(varLogOutput)
+}
+
+// GetLogFilePath returns the path of the file to which the logs are written, in addition
+// to the console output. The file output is disabled if the path is empty (the default).
+// Only one log file can be configured (see log.InitializeLoggerWithConfig).
+func (c *Registry) GetLogFilePath() string {
+	return c.v.GetString(varLogFilePath)
+}
+
+// GetLogFileLevel returns the level of the logs written to the file (default: the level returned by GetLogLevel)
+func (c *Registry) GetLogFileLevel() string {
+	if c.v.IsSet(varLogFileLevel) {
+		return c.v.GetString(varLogFileLevel)
+	}
+	return c.GetLogLevel()
+}
+
+// IsLogFileJSON returns if the logs are written to the file in JSON format (default: true)
+func (c *Registry) IsLogFileJSON() bool {
+	return c.v.GetBool(varLogFileJSON)
+}
+
+// GetLogFileMaxSize returns the size (in megabytes) above which the log file is rotated (default: 100).
+// The size-based rotation is disabled if the size is 0.
+func (c *Registry) GetLogFileMaxSize() int {
+	return c.v.GetInt(varLogFileMaxSize)
+}
+
+// GetLogFileMaxBackups returns the maximum number of rotated log files which are retained (default: 7).
+// All the rotated log files are retained if the number is 0.
+func (c *Registry) GetLogFileMaxBackups() int {
+	return c.v.GetInt(varLogFileMaxBackups)
+}
+
+// GetLogFileMaxAge returns the duration after which the rotated log files are removed (default: 7 days).
+// The rotated log files are retained regardless of their age if the duration is 0.
+func (c *Registry) GetLogFileMaxAge() time.Duration {
+	return c.v.GetDuration(varLogFileMaxAge)
+}
+
+// GetLogFileRotationInterval returns the interval after which the log file is rotated, regardless
+// of its size (default: 24 hours). The time-based rotation is disabled if the interval is 0.
+func (c *Registry) GetLogFileRotationInterval() time.Duration {
+	return c.v.GetDuration(varLogFileRotation)
+}
+
 // DeveloperModeEnabled returns `true` if development related features (as set via default, config file, or environment variable),
 // e.g. token generation endpoint are enabled
 func (c *Registry) DeveloperModeEnabled() bool {
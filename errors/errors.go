@@ -21,7 +21,8 @@ const (
 	ErrInternalDatabase = "database_error"
 )
 
-// FromStatusCode returns an error from the given HTTP status code, using the message and args
+// FromStatusCode returns an error from the given HTTP status code, using the message and args.
+// The errors returned for the 429, 502, 503 and 504 status codes are temporary (see IsTemporary).
 func FromStatusCode(statusCode int, format string, args ...interface{}) error {
 	msg := fmt.Sprintf(format, args...)
 	switch statusCode {
@@ -35,6 +36,8 @@ func FromStatusCode(statusCode int, format string, args ...interface{}) error {
 		return NewUnauthorizedError(msg)
 	case http.StatusForbidden:
 		return NewForbiddenError(msg)
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return NewTemporaryInternalErrorFromString(msg)
 	default:
 		return NewInternalErrorFromString(msg)
 	}
@@ -50,12 +53,18 @@ func (err simpleError) Error() string {
 
 // NewInternalError returns the custom defined error of type InternalError.
 func NewInternalError(ctx context.Context, err error) InternalError {
-	return InternalError{err}
+	return InternalError{Err: err}
 }
 
 // NewInternalErrorFromString returns the custom defined error of type InternalError.
 func NewInternalErrorFromString(errorMessage string) InternalError {
-	return InternalError{errors.New(errorMessage)}
+	return InternalError{Err: errors.New(errorMessage)}
+}
+
+// NewTemporaryInternalErrorFromString returns the custom defined error of type InternalError,
+// flagged as temporary (i.e., the operation may succeed if retried later).
+func NewTemporaryInternalErrorFromString(errorMessage string) InternalError {
+	return InternalError{Err: errors.New(errorMessage), temporary: true}
 }
 
 // IsInternalError returns true if the cause of the given error can be
@@ -100,13 +109,55 @@ func IsForbiddenError(err error) (bool, error) {
 
 // InternalError means that the operation failed for some internal, unexpected reason
 type InternalError struct {
-	Err error
+	Err       error
+	temporary bool
 }
 
 func (ie InternalError) Error() string {
 	return ie.Err.Error()
 }
 
+// Temporary returns true if the error is transient, i.e., if the operation may
+// succeed if retried later.
+func (ie InternalError) Temporary() bool {
+	return ie.temporary || IsTemporary(ie.Err)
+}
+
+// temporary the interface implemented by the errors which can tell if they are transient,
+// such as InternalError or net.Error
+type temporary interface {
+	Temporary() bool
+}
+
+// causer the interface implemented by the errors wrapped with the github.com/pkg/errors package
+type causer interface {
+	Cause() error
+}
+
+// IsTemporary returns true if the given error, or any of the errors it wraps,
+// is transient (i.e., if it has a `Temporary() bool` method which returns true).
+// Such errors can be used to decide whether an operation should be retried.
+//
+// Among the error types of this package, only InternalError can be temporary: the other
+// ones describe a request which is invalid (bad parameter, not found, unauthorized, conflict, etc.)
+// and which would fail again if retried as is. The errors returned by the database driver are
+// not classified here, so that this package does not depend on the driver: the operations on
+// the database should use gormsupport.IsRetryable, which also recognizes the serialization failures,
+// the deadlocks and the connection errors, in addition to the temporary errors.
+func IsTemporary(err error) bool {
+	for err != nil {
+		if t, ok := err.(temporary); ok && t.Temporary() {
+			return true
+		}
+		c, ok := err.(causer)
+		if !ok {
+			return false
+		}
+		err = c.Cause()
+	}
+	return false
+}
+
 // UnauthorizedError means that the operation is unauthorized
 type UnauthorizedError struct {
 	simpleError
@@ -178,6 +229,22 @@ func (err BadParameterError) Error() string {
 
 }
 
+// Parameter returns the name of the parameter which had a bad value
+func (err BadParameterError) Parameter() string {
+	return err.parameter
+}
+
+// Value returns the bad value of the parameter
+func (err BadParameterError) Value() interface{} {
+	return err.value
+}
+
+// ExpectedValue returns the expected value of the parameter and true if it was set,
+// nil and false otherwise
+func (err BadParameterError) ExpectedValue() (interface{}, bool) {
+	return err.expectedValue, err.hasExpectedValue
+}
+
 // Expected sets the optional expectedValue parameter on the BadParameterError
 func (err BadParameterError) Expected(expexcted interface{}) BadParameterError {
 	err.expectedValue = expexcted
@@ -239,6 +306,11 @@ func (err NotFoundError) Error() string {
 	return fmt.Sprintf(stNotFoundErrorMsg, err.entity, err.ID)
 }
 
+// Entity returns the name of the entity that was not found
+func (err NotFoundError) Entity() string {
+	return err.entity
+}
+
 // NewNotFoundError returns the custom defined error of type NewNotFoundError.
 func NewNotFoundError(entity string, id string) NotFoundError {
 	return NotFoundError{entity: entity, ID: id}
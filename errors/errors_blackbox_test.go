@@ -3,6 +3,7 @@ package errors_test
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/fabric8-services/fabric8-common/errors"
@@ -38,8 +39,15 @@ func TestNewBadParameterError(t *testing.T) {
 	expectedValue := 11
 	err := errors.NewBadParameterError(param, value)
 	assert.Equal(t, fmt.Sprintf("Bad value for parameter '%s': '%v'", param, value), err.Error())
+	assert.Equal(t, param, err.Parameter())
+	assert.Equal(t, value, err.Value())
+	_, hasExpected := err.ExpectedValue()
+	assert.False(t, hasExpected)
 	err = errors.NewBadParameterError(param, value).Expected(expectedValue)
 	assert.Equal(t, fmt.Sprintf("Bad value for parameter '%s': '%v' (expected: '%v')", param, value, expectedValue), err.Error())
+	expected, hasExpected := err.ExpectedValue()
+	assert.True(t, hasExpected)
+	assert.Equal(t, expectedValue, expected)
 
 	msg := "this is my predefined message returned from an external source"
 	err = errors.NewBadParameterErrorFromString(msg)
@@ -53,6 +61,8 @@ func TestNewNotFoundError(t *testing.T) {
 	value := "10"
 	err := errors.NewNotFoundError(param, value)
 	assert.Equal(t, fmt.Sprintf("%s with id '%s' not found", param, value), err.Error())
+	assert.Equal(t, param, err.Entity())
+	assert.Equal(t, value, err.ID)
 }
 
 func TestNewUnauthorizedError(t *testing.T) {
@@ -117,3 +127,75 @@ func TestIsXYError(t *testing.T) {
 		})
 	}
 }
+
+func TestFromStatusCode(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testCases := []struct {
+		statusCode        int
+		expectedType      interface{}
+		expectedTemporary bool
+	}{
+		{http.StatusNotFound, errors.NotFoundError{}, false},
+		{http.StatusBadRequest, errors.BadParameterError{}, false},
+		{http.StatusConflict, errors.VersionConflictError{}, false},
+		{http.StatusUnauthorized, errors.UnauthorizedError{}, false},
+		{http.StatusForbidden, errors.ForbiddenError{}, false},
+		{http.StatusInternalServerError, errors.InternalError{}, false},
+		{http.StatusTooManyRequests, errors.InternalError{}, true},
+		{http.StatusBadGateway, errors.InternalError{}, true},
+		{http.StatusServiceUnavailable, errors.InternalError{}, true},
+		{http.StatusGatewayTimeout, errors.InternalError{}, true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(http.StatusText(tc.statusCode), func(t *testing.T) {
+			t.Parallel()
+			err := errors.FromStatusCode(tc.statusCode, "failure with %s", "args")
+			assert.IsType(t, tc.expectedType, err)
+			assert.Equal(t, "failure with args", err.Error())
+			assert.Equal(t, tc.expectedTemporary, errors.IsTemporary(err))
+		})
+	}
+}
+
+type temporaryError struct {
+	temporary bool
+}
+
+func (e temporaryError) Error() string {
+	return "temporary error"
+}
+
+func (e temporaryError) Temporary() bool {
+	return e.temporary
+}
+
+func TestIsTemporary(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	ctx := context.Background()
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil error", nil, false},
+		{"simple error", errs.New("foo"), false},
+		{"internal error", errors.NewInternalErrorFromString("foo"), false},
+		{"not found error", errors.NewNotFoundError("foo", "bar"), false},
+		{"temporary internal error", errors.NewTemporaryInternalErrorFromString("foo"), true},
+		{"wrapped temporary internal error", errs.Wrap(errs.Wrap(errors.NewTemporaryInternalErrorFromString("foo"), "msg1"), "msg2"), true},
+		{"internal error with temporary cause", errors.NewInternalError(ctx, temporaryError{true}), true},
+		{"internal error with non-temporary cause", errors.NewInternalError(ctx, temporaryError{false}), false},
+		{"wrapped temporary error", errs.Wrap(temporaryError{true}, "msg"), true},
+		{"wrapped non-temporary error", errs.Wrap(temporaryError{false}, "msg"), false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, errors.IsTemporary(tc.err))
+		})
+	}
+}
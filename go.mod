@@ -0,0 +1,92 @@
+module github.com/fabric8-services/fabric8-common
+
+go 1.21
+
+require (
+	github.com/davecgh/go-spew v1.1.1
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/dnaeon/go-vcr v0.0.0-20180920040454-5637cf3d8a31
+	github.com/fabric8-services/fabric8-auth-client v0.0.0-20181030170214-0eb93fc6cee1
+	github.com/fzipp/gocyclo v0.0.0-20150627053110-6acd4345c835
+	github.com/getsentry/sentry-go v0.11.0
+	github.com/goadesign/goa v1.3.0
+	github.com/gojuno/minimock v1.9.2
+	github.com/golang/lint v0.0.0-20180702182130-06c8688daad7
+	github.com/jinzhu/gorm v1.9.1
+	github.com/jteeuwen/go-bindata v3.0.7+incompatible
+	github.com/lib/pq v0.0.0-20180523175426-90697d60dd84
+	github.com/pilu/fresh v0.0.0-20170301142741-9c0092493eff
+	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v1.4.1
+	github.com/prometheus/client_model v0.2.0
+	github.com/satori/go.uuid v1.2.0
+	github.com/sirupsen/logrus v1.4.2
+	github.com/spf13/viper v1.3.2
+	github.com/stretchr/testify v1.4.0
+	github.com/wadey/gocovmerge v0.0.0-20160331181800-b5bfa59ec0ad
+	gopkg.in/h2non/gock.v1 v1.0.12
+	gopkg.in/square/go-jose.v2 v2.1.6
+	gopkg.in/yaml.v2 v2.2.5
+)
+
+require (
+	github.com/PuerkitoBio/purell v1.1.0 // indirect
+	github.com/PuerkitoBio/urlesc v0.0.0-20170810143723-de5bf2ad4578 // indirect
+	github.com/ajg/form v1.5.1 // indirect
+	github.com/armon/go-metrics v0.0.0-20180917152333-f0300d1749da // indirect
+	github.com/asaskevich/govalidator v0.0.0-20180720115003-f9ffefc3facf // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/denisenkom/go-mssqldb v0.0.0-20180620032804-94c9c97e8c9f // indirect
+	github.com/dimfeld/httppath v0.0.0-20170720192232-ee938bf73598 // indirect
+	github.com/dimfeld/httptreemux v5.0.1+incompatible // indirect
+	github.com/erikstmartin/go-testdb v0.0.0-20160219214506-8d10e4a1bae5 // indirect
+	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/go-openapi/analysis v0.0.0-20171215055114-2bbaa248df98 // indirect
+	github.com/go-openapi/errors v0.0.0-20170426151106-03cfca65330d // indirect
+	github.com/go-openapi/jsonpointer v0.0.0-20170102174223-779f45308c19 // indirect
+	github.com/go-openapi/jsonreference v0.0.0-20180322222742-3fb327e6747d // indirect
+	github.com/go-openapi/loads v0.0.0-20171207192234-2a2b323bab96 // indirect
+	github.com/go-openapi/spec v0.0.0-20171219195406-fa03337d7da5 // indirect
+	github.com/go-openapi/strfmt v0.0.0-20171222154016-4dd3d302e100 // indirect
+	github.com/go-openapi/swag v0.0.0-20171111214437-cf0bdb963811 // indirect
+	github.com/go-sql-driver/mysql v1.4.0 // indirect
+	github.com/gojuno/generator v0.0.0-20180725114326-487ec858da35 // indirect
+	github.com/golang/protobuf v1.3.2 // indirect
+	github.com/hashicorp/go-immutable-radix v0.0.0-20180129170900-7f3cd4390caa // indirect
+	github.com/hashicorp/go-uuid v1.0.0 // indirect
+	github.com/hashicorp/golang-lru v0.0.0-20180201235237-0fb14efe8c47 // indirect
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/howeyc/fsnotify v0.9.0 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/jinzhu/inflection v0.0.0-20180308033659-04140366298a // indirect
+	github.com/jinzhu/now v0.0.0-20181116074157-8ec929ed50c3 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/magiconair/properties v1.8.0 // indirect
+	github.com/mailru/easyjson v0.0.0-20180323154445-8b799c424f57 // indirect
+	github.com/manveru/faker v0.0.0-20171103152722-9fbc68a78c4d // indirect
+	github.com/marwan-at-work/vgop v0.0.0-20180824202541-054e5a7d7b48 // indirect
+	github.com/mattn/go-colorable v0.1.2 // indirect
+	github.com/mattn/go-isatty v0.0.9 // indirect
+	github.com/mattn/go-sqlite3 v1.9.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/mitchellh/mapstructure v1.1.2 // indirect
+	github.com/nbio/st v0.0.0-20140626010706-e9e8d9816f32 // indirect
+	github.com/pelletier/go-toml v1.2.0 // indirect
+	github.com/pilu/config v0.0.0-20131214182432-3eb99e6c0b9a // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/common v0.9.1 // indirect
+	github.com/prometheus/procfs v0.0.8 // indirect
+	github.com/spf13/afero v1.1.2 // indirect
+	github.com/spf13/cast v1.3.0 // indirect
+	github.com/spf13/cobra v0.0.5 // indirect
+	github.com/spf13/jwalterweatherman v1.0.0 // indirect
+	github.com/spf13/pflag v1.0.3 // indirect
+	github.com/zach-klippenstein/goregen v0.0.0-20160303162051-795b5e3961ea // indirect
+	golang.org/x/crypto v0.0.0-20191227163750-53104e6ec876 // indirect
+	golang.org/x/lint v0.0.0-20181026193005-c67002cb31c3 // indirect
+	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553 // indirect
+	golang.org/x/sys v0.0.0-20200122134326-e047566fdf82 // indirect
+	golang.org/x/text v0.3.2 // indirect
+	golang.org/x/tools v0.0.0-20190328211700-ab21143f2384 // indirect
+)
@@ -3,12 +3,15 @@ package goasupport
 import (
 	"context"
 
+	"github.com/fabric8-services/fabric8-common/requestid"
+
 	"github.com/goadesign/goa/client"
-	"github.com/goadesign/goa/middleware"
 )
 
+// ForwardContextRequestID returns a copy of the given context in which the request ID of the incoming
+// request (see requestid.FromContext) is set as the request ID of the goa clients.
 func ForwardContextRequestID(ctx context.Context) context.Context {
-	reqID := middleware.ContextRequestID(ctx)
+	reqID := requestid.FromContext(ctx)
 	if reqID != "" {
 		return client.SetContextRequestID(ctx, reqID)
 	}
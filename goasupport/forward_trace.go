@@ -0,0 +1,26 @@
+package goasupport
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-common/tracing"
+
+	"github.com/goadesign/goa/client"
+)
+
+// NewTracingDoer returns a goa client Doer which traces each request sent with the given Doer
+// with a client span, propagated in the `traceparent` header (see tracing.Do)
+func NewTracingDoer(doer client.Doer) client.Doer {
+	return &tracingDoer{doer: doer}
+}
+
+type tracingDoer struct {
+	doer client.Doer
+}
+
+func (d *tracingDoer) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return tracing.Do(ctx, req, func(r *http.Request) (*http.Response, error) {
+		return d.doer.Do(ctx, r)
+	})
+}
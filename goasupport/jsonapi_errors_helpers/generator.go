@@ -3,20 +3,18 @@ package helperfunctions
 import (
 	"flag"
 	"fmt"
-	"go/parser"
-	"go/token"
-	"io/ioutil"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strings"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
 	errs "github.com/pkg/errors"
 )
 
-// Generate adds method to support conditional queries
+// Generate writes the glue code between the generated `app` package and the
+// `github.com/fabric8-services/fabric8-common/jsonapi` package. The error handling
+// and the conversion of errors into JSON-API errors are provided by the latter, so
+// only the functions which depend on the generated types are written here.
 func Generate() ([]string, error) {
 	var (
 		ver    string
@@ -27,158 +25,121 @@ func Generate() ([]string, error) {
 	set.StringVar(&ver, "version", "", "")
 	set.StringVar(&outDir, "out", "", "")
 	set.Parse(os.Args[2:])
-	for _, arg := range os.Args {
-		fmt.Println(arg)
-	}
 	// First check compatibility
 	if err := codegen.CheckVersion(ver); err != nil {
 		return nil, err
 	}
-	// return writeFunctions(design.Design, outDir)
-	files := make([]string, 0)
-	// the `jsonapi_utility.txt` file is in the same directory as this function itself
-	for file, title := range map[string]string{
-		"error_handler.go.txt":                          "helper functions to handle errors",
-		"jsonapi_errors_stringer.go.txt":                "stringer functions for JSONAPI Error(s)",
-		"jsonapi_errors_converter.go.txt":               "helper functions to convert to JSONAPI Errors",
-		"jsonapi_errors_converter_blackbox_test.go.txt": "tests for the helper functions to convert to JSONAPI Errors",
-	} {
-		result, err := writeFile(file, title, design.Design, outDir)
-		if err != nil {
-			return []string{}, err
-		}
-		files = append(files, result)
-	}
-	return files, nil
-}
-
-func writeFile(sourceFile, title string, api *design.APIDefinition, outDir string) (string, error) {
-	var caller string
-	var ok bool
-	if _, caller, _, ok = runtime.Caller(1); !ok {
-		return "", errs.Errorf("failed to generate the JSON-API Errors helpers")
-	}
-
-	// looks-up the .go source file in this directory, parse it and generates the output file
-	// in the target directory
-	fset := token.NewFileSet() // positions are relative to fset
-	// read the source file
-	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(caller), sourceFile))
-	if err != nil {
-		return "", errs.Wrapf(err, "failed to generate the JSON-API Errors helper from %s", sourceFile)
-	}
-	f, err := parser.ParseFile(fset, sourceFile, content, parser.ImportsOnly)
-	if err != nil {
-		return "", errs.Wrapf(err, "failed to generate the JSON-API Errors helper from %s", sourceFile)
-	}
-
-	// computes the name of the file by removing all extensions, then adding `.go`
-	targetFile := sourceFile
-	for {
-		ext := filepath.Ext(targetFile)
-		if ext == "" {
-			break
-		}
-		targetFile = strings.TrimRight(targetFile, ext)
-	}
-	targetFile = fmt.Sprintf("%s.go", targetFile)
-	ctxFile := filepath.Join(outDir, targetFile)
-	ctxWr, err := codegen.SourceFileFor(ctxFile)
-	if err != nil {
-		return "", errs.Wrapf(err, "failed to generate the JSON-API Errors helper from %s", sourceFile)
-	}
-	imports := make([]*codegen.ImportSpec, len(f.Imports))
-	for i, s := range f.Imports {
-		// fmt.Println(s.Path.Value)
-		if s.Name != nil {
-			imports[i] = codegen.NewImport(s.Name.String(), strings.Trim(s.Path.Value, `"`))
-		} else {
-			imports[i] = codegen.SimpleImport(strings.Trim(s.Path.Value, `"`))
-		}
-	}
-	ctxWr.WriteHeader(title, "app", imports)
-	endOfImports := f.Decls[0].End()
-	ctxWr.Write(content[endOfImports:])
-
-	return "", nil
+	return writeJSONAPIErrorsFile(design.Design, outDir)
 }
 
-// writeJSONAPIErrorConverterFile creates the `jsonapi_errors_converter.go` file.
-func writeJSONAPIErrorConverterFile(api *design.APIDefinition, outDir string) ([]string, error) {
-	ctxFile := filepath.Join(outDir, "jsonapi_errors_converter.go")
+// writeJSONAPIErrorsFile creates the `jsonapi_errors.go` file.
+func writeJSONAPIErrorsFile(api *design.APIDefinition, outDir string) ([]string, error) {
+	ctxFile := filepath.Join(outDir, "jsonapi_errors.go")
 	ctxWr, err := codegen.SourceFileFor(ctxFile)
 	if err != nil {
-		panic(err) // bug
+		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
 	}
-	title := fmt.Sprintf("%s: functions for JSONAPI Errors - See vendor/fabric8-services/fabric8-common/goasupport/jsonapi_errors_stringer/generator.go", api.Context())
+	title := fmt.Sprintf("%s: helper functions to return JSON-API Errors - See vendor/fabric8-services/fabric8-common/goasupport/jsonapi_errors_helpers/generator.go", api.Context())
 	imports := []*codegen.ImportSpec{
-		codegen.SimpleImport("fmt"),
-		codegen.SimpleImport("github.com/davecgh/go-spew/spew"),
 		codegen.SimpleImport("context"),
 		codegen.SimpleImport("net/http"),
-		codegen.SimpleImport("reflect"),
-		codegen.SimpleImport("strconv"),
-		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/errors"),
-		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/log"),
-		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/sentry"),
-		codegen.SimpleImport("github.com/goadesign/goa"),
-		codegen.NewImport("errs", "github.com/pkg/errors"),
+		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/jsonapi"),
 	}
 	ctxWr.WriteHeader(title, "app", imports)
-	var f string
-	var ok bool
-	if _, f, _, ok = runtime.Caller(1); !ok {
-		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
-	}
-	// the `jsonapi_utility.txt` file is in the same directory as this function itself
-	f = filepath.Join(filepath.Dir(f), "jsonapi_utility.txt")
-	fmt.Printf("including content from %s\n", f)
-	body, err := ioutil.ReadFile(f)
-	if err != nil {
-		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
-	}
-	if _, err := ctxWr.Write(body); err != nil {
+	if err := ctxWr.ExecuteTemplate("jsonapiErrors", jsonapiErrors, nil, nil); err != nil {
 		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
 	}
 	return []string{ctxFile}, nil
 }
 
-// writeJSONAPIErrorHandlerFile creates the `jsonapi_errors_handler.go` file.
-func writeJSONAPIErrorHandlerFile(api *design.APIDefinition, outDir string) ([]string, error) {
-	ctxFile := filepath.Join(outDir, "jsonapi_errors_converter.go")
-	ctxWr, err := codegen.SourceFileFor(ctxFile)
-	if err != nil {
-		panic(err) // bug
-	}
-	title := fmt.Sprintf("%s: String functions for JSONAPI Errors - See vendor/fabric8-services/fabric8-common/goasupport/jsonapi_errors_stringer/generator.go", api.Context())
-	imports := []*codegen.ImportSpec{
-		codegen.SimpleImport("fmt"),
-		codegen.SimpleImport("github.com/davecgh/go-spew/spew"),
-		codegen.SimpleImport("context"),
-		codegen.SimpleImport("net/http"),
-		codegen.SimpleImport("reflect"),
-		codegen.SimpleImport("strconv"),
-		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/errors"),
-		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/log"),
-		codegen.SimpleImport("github.com/fabric8-services/fabric8-common/sentry"),
-		codegen.SimpleImport("github.com/goadesign/goa"),
-		codegen.NewImport("errs", "github.com/pkg/errors"),
-	}
-	ctxWr.WriteHeader(title, "app", imports)
-	var f string
-	var ok bool
-	if _, f, _, ok = runtime.Caller(1); !ok {
-		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
-	}
-	// the `jsonapi_utility.txt` file is in the same directory as this function itself
-	f = filepath.Join(filepath.Dir(f), "jsonapi_utility.txt")
-	fmt.Printf("including content from %s\n", f)
-	body, err := ioutil.ReadFile(f)
-	if err != nil {
-		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
-	}
-	if _, err := ctxWr.Write(body); err != nil {
-		return nil, errs.Wrapf(err, "failed to generate the JSON-API Errors helpers")
+const (
+	jsonapiErrors = `// ErrorToJSONAPIErrors converts the given error into the JSONAPIErrors media type of this API,
+// along with the HTTP status code that will be associated with it.
+func ErrorToJSONAPIErrors(ctx context.Context, err error) (*JSONAPIErrors, int) {
+	jerrs, status := jsonapi.ErrorToJSONAPIErrors(ctx, err)
+	return convertJSONAPIErrors(jerrs), status
+}
+
+// convertJSONAPIErrors converts the JSON-API errors into the JSONAPIErrors media type of this API.
+func convertJSONAPIErrors(jerrs *jsonapi.JSONAPIErrors) *JSONAPIErrors {
+	result := JSONAPIErrors{}
+	for _, e := range jerrs.Errors {
+		result.Errors = append(result.Errors, &JSONAPIError{
+			Code:   e.Code,
+			Detail: e.Detail,
+			ID:     e.ID,
+			Meta:   e.Meta,
+			Source: e.Source,
+			Status: e.Status,
+			Title:  e.Title,
+		})
+	}
+	return &result
+}
+
+// BadRequestContext represent a Context that can return a BadRequest HTTP status
+type BadRequestContext interface {
+	context.Context
+	BadRequest(*JSONAPIErrors) error
+}
+
+// InternalServerErrorContext represent a Context that can return a InternalServerError HTTP status
+type InternalServerErrorContext interface {
+	context.Context
+	InternalServerError(*JSONAPIErrors) error
+}
+
+// NotFoundContext represent a Context that can return a NotFound HTTP status
+type NotFoundContext interface {
+	context.Context
+	NotFound(*JSONAPIErrors) error
+}
+
+// UnauthorizedContext represent a Context that can return a Unauthorized HTTP status
+type UnauthorizedContext interface {
+	context.Context
+	Unauthorized(*JSONAPIErrors) error
+}
+
+// ForbiddenContext represent a Context that can return a Forbidden HTTP status
+type ForbiddenContext interface {
+	context.Context
+	Forbidden(*JSONAPIErrors) error
+}
+
+// ConflictContext represent a Context that can return a Conflict HTTP status
+type ConflictContext interface {
+	context.Context
+	Conflict(*JSONAPIErrors) error
+}
+
+// JSONErrorResponse auto maps the provided error to the correct response type
+// If all else fails, InternalServerError is returned
+func JSONErrorResponse(ctx InternalServerErrorContext, err error) error {
+	jsonErr, status := ErrorToJSONAPIErrors(ctx, err)
+	switch status {
+	case http.StatusBadRequest:
+		if ctx, ok := ctx.(BadRequestContext); ok {
+			return ctx.BadRequest(jsonErr)
+		}
+	case http.StatusNotFound:
+		if ctx, ok := ctx.(NotFoundContext); ok {
+			return ctx.NotFound(jsonErr)
+		}
+	case http.StatusUnauthorized:
+		if ctx, ok := ctx.(UnauthorizedContext); ok {
+			return ctx.Unauthorized(jsonErr)
+		}
+	case http.StatusForbidden:
+		if ctx, ok := ctx.(ForbiddenContext); ok {
+			return ctx.Forbidden(jsonErr)
+		}
+	case http.StatusConflict:
+		if ctx, ok := ctx.(ConflictContext); ok {
+			return ctx.Conflict(jsonErr)
+		}
 	}
-	return []string{ctxFile}, nil
+	return ctx.InternalServerError(jsonErr)
 }
+`
+)
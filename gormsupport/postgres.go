@@ -1,12 +1,30 @@
 package gormsupport
 
-import "github.com/lib/pq"
+import (
+	"database/sql/driver"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+
+	"github.com/lib/pq"
+	errs "github.com/pkg/errors"
+)
 
 const (
-	errCheckViolation      = "23514"
-	errUniqueViolation     = "23505"
-	errForeignKeyViolation = "23503"
-	errInvalidCatalogName  = "3D000"
+	errCheckViolation       = "23514"
+	errUniqueViolation      = "23505"
+	errForeignKeyViolation  = "23503"
+	errInvalidCatalogName   = "3D000"
+	errSerializationFailure = "40001"
+	errDeadlockDetected     = "40P01"
+	errTooManyConnections   = "53300"
+	errAdminShutdown        = "57P01"
+	errCrashShutdown        = "57P02"
+	errCannotConnectNow     = "57P03"
+	// errConnectionExceptionClass the class of the `connection_exception` codes (08000, 08003, 08006, etc.)
+	errConnectionExceptionClass = "08"
 )
 
 // IsCheckViolation returns true if the error is a violation of the given check
@@ -57,3 +75,71 @@ func IsForeignKeyViolation(err error, indexName string) bool {
 	}
 	return pqError.Code == errForeignKeyViolation && pqError.Constraint == indexName
 }
+
+// IsSerializationFailure returns true if the cause of the given error is a
+// serialization failure of a transaction (e.g. in `REPEATABLE READ` or `SERIALIZABLE` isolation level)
+func IsSerializationFailure(err error) bool {
+	pqError, ok := cause(err).(*pq.Error)
+	if !ok {
+		return false
+	}
+	return pqError.Code == errSerializationFailure
+}
+
+// IsDeadlockDetected returns true if the cause of the given error is a deadlock
+// detected by the database
+func IsDeadlockDetected(err error) bool {
+	pqError, ok := cause(err).(*pq.Error)
+	if !ok {
+		return false
+	}
+	return pqError.Code == errDeadlockDetected
+}
+
+// IsConnectionError returns true if the cause of the given error is a failure
+// to connect or a lost connection to the database
+func IsConnectionError(err error) bool {
+	c := cause(err)
+	if c == nil {
+		return false
+	}
+	if c == driver.ErrBadConn || c == io.EOF || c == io.ErrUnexpectedEOF {
+		return true
+	}
+	if _, ok := c.(net.Error); ok {
+		return true
+	}
+	pqError, ok := c.(*pq.Error)
+	if !ok {
+		return false
+	}
+	switch pqError.Code {
+	case errTooManyConnections, errAdminShutdown, errCrashShutdown, errCannotConnectNow:
+		return true
+	}
+	return strings.HasPrefix(string(pqError.Code), errConnectionExceptionClass)
+}
+
+// IsRetryable returns true if the given error is transient, i.e., if the operation
+// (or the whole transaction) that failed may succeed if retried: serialization failures,
+// deadlocks, connection errors, as well as the temporary errors (see errors.IsTemporary).
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return IsSerializationFailure(err) || IsDeadlockDetected(err) || IsConnectionError(err) || errors.IsTemporary(err)
+}
+
+// cause returns the underlying cause of the given error, i.e., the innermost error wrapped with the
+// github.com/pkg/errors package or in an errors.InternalError (which `errs.Cause` does not see through
+// since InternalError has no `Cause() error` method).
+func cause(err error) error {
+	for {
+		err = errs.Cause(err)
+		ie, ok := err.(errors.InternalError)
+		if !ok {
+			return err
+		}
+		err = ie.Err
+	}
+}
@@ -0,0 +1,59 @@
+package gormsupport_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"net"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/gormsupport"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/lib/pq"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name                 string
+		err                  error
+		serializationFailure bool
+		deadlockDetected     bool
+		connectionError      bool
+		retryable            bool
+	}{
+		{"nil error", nil, false, false, false, false},
+		{"simple error", errs.New("foo"), false, false, false, false},
+		{"unique violation", &pq.Error{Code: "23505"}, false, false, false, false},
+		{"serialization failure", &pq.Error{Code: "40001"}, true, false, false, true},
+		{"wrapped serialization failure", errs.Wrap(&pq.Error{Code: "40001"}, "msg"), true, false, false, true},
+		{"deadlock detected", &pq.Error{Code: "40P01"}, false, true, false, true},
+		{"connection failure", &pq.Error{Code: "08006"}, false, false, true, true},
+		{"connection does not exist", &pq.Error{Code: "08003"}, false, false, true, true},
+		{"too many connections", &pq.Error{Code: "53300"}, false, false, true, true},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, false, false, true, true},
+		{"bad connection", driver.ErrBadConn, false, false, true, true},
+		{"network error", errs.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errs.New("connection refused")}, "msg"), false, false, true, true},
+		{"temporary error", errors.NewTemporaryInternalErrorFromString("foo"), false, false, false, true},
+		{"internal error with a serialization failure", errors.NewInternalError(ctx, &pq.Error{Code: "40001"}), true, false, false, true},
+		{"wrapped internal error with a deadlock", errs.Wrap(errors.NewInternalError(ctx, errs.Wrap(&pq.Error{Code: "40P01"}, "msg1")), "msg2"), false, true, false, true},
+		{"internal error with a connection failure", errors.NewInternalError(ctx, &pq.Error{Code: "08006"}), false, false, true, true},
+		{"internal error with a unique violation", errors.NewInternalError(ctx, &pq.Error{Code: "23505"}), false, false, false, false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.serializationFailure, gormsupport.IsSerializationFailure(tc.err))
+			assert.Equal(t, tc.deadlockDetected, gormsupport.IsDeadlockDetected(tc.err))
+			assert.Equal(t, tc.connectionError, gormsupport.IsConnectionError(tc.err))
+			assert.Equal(t, tc.retryable, gormsupport.IsRetryable(tc.err))
+		})
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 	"context"
 
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/tracing"
+
 	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/client"
 )
@@ -35,8 +38,11 @@ func DefaultHTTPDoer() HTTPDoer {
 }
 
 // Do overrides Do method of the default goa client Doer. It's needed for mocking http clients in tests.
+// The request ID stored in the given context (if any) is sent in the `X-Request-ID` header, and the request
+// is traced with a client span propagated in the `traceparent` header.
 func (d *HTTPClientDoer) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	return d.HTTPClient.Do(req)
+	requestid.SetHeader(ctx, req)
+	return tracing.Do(ctx, req, d.HTTPClient.Do)
 }
 
 // Configuration the minimum configuration to perform some URL transformation
@@ -1,13 +1,16 @@
 package httpsupport_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
 	"testing"
 
 	"github.com/fabric8-services/fabric8-common/httpsupport"
+	"github.com/fabric8-services/fabric8-common/requestid"
 	"github.com/fabric8-services/fabric8-common/resource"
+	"github.com/fabric8-services/fabric8-common/tracing"
 
 	"github.com/goadesign/goa"
 	"github.com/stretchr/testify/assert"
@@ -97,3 +100,47 @@ func equalURLs(t *testing.T, expectedURL *url.URL, actual string) {
 		assert.Equal(t, value, actualURL.Query()[name])
 	}
 }
+
+type recordingClient struct {
+	req *http.Request
+}
+
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	c.req = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestHTTPClientDoerForwardsRequestID(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given
+	c := &recordingClient{}
+	doer := &httpsupport.HTTPClientDoer{HTTPClient: c}
+	ctx := requestid.NewContext(context.Background(), "foo")
+	req, err := http.NewRequest(http.MethodGet, "https://auth.openshift.io/api/status", nil)
+	require.NoError(t, err)
+	// when
+	_, err = doer.Do(ctx, req)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "foo", c.req.Header.Get(requestid.Header))
+}
+
+func TestHTTPClientDoerPropagatesTraceContext(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given
+	c := &recordingClient{}
+	doer := &httpsupport.HTTPClientDoer{HTTPClient: c}
+	ctx, span := tracing.StartSpan(context.Background(), "server", tracing.SpanKindServer)
+	req, err := http.NewRequest(http.MethodGet, "https://auth.openshift.io/api/status", nil)
+	require.NoError(t, err)
+	// when
+	_, err = doer.Do(ctx, req)
+	// then
+	require.NoError(t, err)
+	sc, err := tracing.ParseTraceparent(c.req.Header.Get(tracing.TraceparentHeader))
+	require.NoError(t, err)
+	assert.Equal(t, span.SpanContext().TraceID, sc.TraceID)
+	assert.NotEqual(t, span.SpanContext().SpanID, sc.SpanID)
+}
@@ -0,0 +1,103 @@
+package httpsupport
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/metric"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clientMetricsOnce   sync.Once
+	clientReqCnt        *prometheus.CounterVec
+	clientReqDuration   *prometheus.HistogramVec
+	clientReqErrCnt     *prometheus.CounterVec
+	clientMetricsLabels = []string{"host", "code"}
+)
+
+// registerClientMetrics registers the metrics of the outgoing requests in the default Prometheus registry
+// the first time it is called
+func registerClientMetrics() {
+	clientMetricsOnce.Do(func() {
+		clientReqCnt = metric.Register(
+			prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "http_client_requests_total",
+				Help: "Counter of the outgoing HTTP requests which received a response.",
+			}, clientMetricsLabels),
+			"http_client_requests_total").(*prometheus.CounterVec)
+		clientReqDuration = metric.Register(
+			prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:    "http_client_request_duration_seconds",
+				Help:    "Bucketed histogram of the duration (s) of the outgoing HTTP requests.",
+				Buckets: prometheus.ExponentialBuckets(0.05, 2, 8),
+			}, clientMetricsLabels),
+			"http_client_request_duration_seconds").(*prometheus.HistogramVec)
+		clientReqErrCnt = metric.Register(
+			prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "http_client_request_errors_total",
+				Help: "Counter of the outgoing HTTP requests which failed without a response (e.g. connection errors or timeouts).",
+			}, []string{"host"}),
+			"http_client_request_errors_total").(*prometheus.CounterVec)
+	})
+}
+
+// instrumentedRoundTripper a round-tripper which records the metrics of the requests it sends
+type instrumentedRoundTripper struct {
+	next http.RoundTripper
+}
+
+// NewInstrumentedRoundTripper returns a round-tripper which sends the requests with the given round-tripper
+// (or http.DefaultTransport if nil), and records their count and duration labelled by target `host`
+// and status class (e.g. "2xx") in the `code` label, as well as the requests which failed without
+// a response. The metrics are registered with metric.Register.
+func NewInstrumentedRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if _, ok := next.(*instrumentedRoundTripper); ok {
+		// no need to record the requests twice
+		return next
+	}
+	registerClientMetrics()
+	return &instrumentedRoundTripper{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	startTime := time.Now()
+	res, err := r.next.RoundTrip(req)
+	host := req.URL.Host
+	if err != nil {
+		clientReqErrCnt.WithLabelValues(host).Inc()
+		return res, err
+	}
+	code := strconv.Itoa(res.StatusCode/100) + "xx"
+	clientReqCnt.WithLabelValues(host, code).Inc()
+	clientReqDuration.WithLabelValues(host, code).Observe(time.Since(startTime).Seconds())
+	return res, nil
+}
+
+// WithInstrumentedRoundTripper instruments the client's transport (see NewInstrumentedRoundTripper).
+// When combined with WithRoundTripper, this option must be passed after it.
+//
+// Usage example:
+//
+//	keys, err := jwk.FetchKeys(keysEndpointURL, httpsupport.WithInstrumentedRoundTripper())
+func WithInstrumentedRoundTripper() HTTPClientOption {
+	return func(client *http.Client) {
+		client.Transport = NewInstrumentedRoundTripper(client.Transport)
+	}
+}
+
+// WithInstrumentedProxyTransport instruments the proxy's transport (see NewInstrumentedRoundTripper).
+// When combined with WithProxyTransport, this option must be passed after it.
+func WithInstrumentedProxyTransport() HTTPProxyOption {
+	return func(proxy *httputil.ReverseProxy) {
+		proxy.Transport = NewInstrumentedRoundTripper(proxy.Transport)
+	}
+}
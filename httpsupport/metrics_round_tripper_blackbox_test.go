@@ -0,0 +1,85 @@
+package httpsupport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/httpsupport"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedRoundTripper(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+
+	t.Run("responses by status class", func(t *testing.T) {
+		// given the option applied twice
+		client := &http.Client{}
+		httpsupport.WithInstrumentedRoundTripper()(client)
+		httpsupport.WithInstrumentedRoundTripper()(client)
+		// when
+		for _, path := range []string{"/ok", "/ok", "/fail"} {
+			res, err := client.Get(srv.URL + path)
+			require.NoError(t, err)
+			res.Body.Close()
+		}
+		// then the requests are recorded once
+		assert.Equal(t, float64(2), gatherClientMetric(t, "http_client_requests_total", host, "2xx").GetCounter().GetValue())
+		assert.Equal(t, float64(1), gatherClientMetric(t, "http_client_requests_total", host, "5xx").GetCounter().GetValue())
+		assert.Equal(t, uint64(2), gatherClientMetric(t, "http_client_request_duration_seconds", host, "2xx").GetHistogram().GetSampleCount())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		// given a server which is not listening anymore
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		client := &http.Client{}
+		httpsupport.WithInstrumentedRoundTripper()(client)
+		// when
+		_, err := client.Get(closed.URL)
+		// then
+		require.Error(t, err)
+		u, err := url.Parse(closed.URL)
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), gatherClientMetric(t, "http_client_request_errors_total", u.Host, "").GetCounter().GetValue())
+	})
+}
+
+// gatherClientMetric returns the metric with the given name and labels from the default registry
+func gatherClientMetric(t *testing.T, name, host, code string) *dto.Metric {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["host"] == host && labels["code"] == code {
+				return m
+			}
+		}
+	}
+	t.Fatalf("metric %s{host=%s,code=%s} not found", name, host, code)
+	return nil
+}
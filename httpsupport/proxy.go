@@ -11,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/tracing"
 
 	"github.com/goadesign/goa"
 	"github.com/pkg/errors"
@@ -139,10 +141,11 @@ func newDirector(ctx context.Context, originalRequestData *goa.RequestData, targ
 			// explicitly disable User-Agent so it's not set to default value
 			req.Header.Set("User-Agent", "")
 		}
-		requestID := log.ExtractRequestID(ctx)
+		requestID := requestid.FromContext(ctx)
 		if requestID != "" {
-			req.Header.Set("X-Request-ID", requestID)
+			req.Header.Set(requestid.Header, requestID)
 		}
+		tracing.Inject(ctx, req.Header)
 
 		// Log the original and target URLs
 		originalReqString := originalReq.String()
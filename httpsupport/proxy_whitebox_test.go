@@ -146,7 +146,8 @@ func (s *ProxyTestSuite) TestFailsIfInvalidTargetURL() {
 
 	err = RouteHTTP(goaCtx, "%@#", nil)
 	require.Error(s.T(), err)
-	assert.Equal(s.T(), "parse %@: invalid URL escape \"%@\"", err.Error())
+	// the URL is quoted in the error message since Go 1.14
+	assert.Contains(s.T(), err.Error(), "invalid URL escape \"%@\"")
 }
 
 type statusContext struct {
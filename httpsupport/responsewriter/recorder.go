@@ -0,0 +1,91 @@
+// Package responsewriter provides a response writer which records the status and the length of the
+// responses, and the status of the responses of the goa requests, shared by the HTTP middlewares of the
+// audit, log, metric and tracing packages. It is not part of the httpsupport package, which depends on
+// these packages.
+package responsewriter
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Recorder a response writer which records the status and the length of the response, and which forwards
+// the optional interfaces of the wrapped response writer: http.Flusher, http.Hijacker, http.CloseNotifier
+// and http.Pusher. The methods of these interfaces fail (or do nothing) if the wrapped response writer does
+// not implement them.
+type Recorder struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+// NewRecorder returns a new Recorder wrapping the given response writer
+func NewRecorder(rw http.ResponseWriter) *Recorder {
+	return &Recorder{
+		ResponseWriter: rw,
+		status:         http.StatusOK,
+	}
+}
+
+// Status returns the status of the response (200 if it was not set explicitly)
+func (r *Recorder) Status() int {
+	return r.status
+}
+
+// Length returns the number of bytes written in the body of the response
+func (r *Recorder) Length() int {
+	return r.length
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *Recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (r *Recorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.length += n
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped response writer does
+func (r *Recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the wrapped response writer does, e.g. for the websockets.
+// The status of a hijacked response is 101 (Switching Protocols).
+func (r *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer does not implement http.Hijacker")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// CloseNotify implements http.CloseNotifier if the wrapped response writer does, e.g. for the
+// server-sent events. Otherwise, the returned channel never receives a value.
+func (r *Recorder) CloseNotify() <-chan bool {
+	if n, ok := r.ResponseWriter.(http.CloseNotifier); ok {
+		return n.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Push implements http.Pusher if the wrapped response writer does
+func (r *Recorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,80 @@
+package responsewriter_test
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/httpsupport/responsewriter"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+
+	t.Run("status and length", func(t *testing.T) {
+		// given
+		rw := httptest.NewRecorder()
+		r := responsewriter.NewRecorder(rw)
+		// when
+		r.WriteHeader(http.StatusCreated)
+		r.Write([]byte("hello"))
+		r.Flush()
+		// then
+		assert.Equal(t, http.StatusCreated, r.Status())
+		assert.Equal(t, 5, r.Length())
+		assert.True(t, rw.Flushed)
+	})
+
+	t.Run("default status", func(t *testing.T) {
+		// when
+		r := responsewriter.NewRecorder(httptest.NewRecorder())
+		// then
+		assert.Equal(t, http.StatusOK, r.Status())
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		// given a server which hijacks the connection through the recorder
+		statuses := make(chan int, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			r := responsewriter.NewRecorder(rw)
+			var w http.ResponseWriter = r
+			h, ok := w.(http.Hijacker)
+			require.True(t, ok)
+			conn, buf, err := h.Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\nhijacked\n")
+			buf.Flush()
+			statuses <- r.Status()
+		}))
+		defer srv.Close()
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		// when
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+		require.NoError(t, err)
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, http.StatusSwitchingProtocols, <-statuses)
+	})
+
+	t.Run("hijack not supported", func(t *testing.T) {
+		// given
+		r := responsewriter.NewRecorder(httptest.NewRecorder())
+		// when
+		_, _, err := r.Hijack()
+		// then
+		require.Error(t, err)
+		assert.Equal(t, http.ErrNotSupported, r.Push("/foo", nil))
+	})
+}
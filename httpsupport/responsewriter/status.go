@@ -0,0 +1,21 @@
+package responsewriter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goadesign/goa"
+)
+
+// GoaStatus returns the status of the response of the goa request handled in the given context, i.e., the status
+// set by the handler (or by an error handler), or else 500 if the handler returned the given non-nil error, which was
+// then not handled by an error handler mounted after the middleware calling this function, or else 200.
+func GoaStatus(ctx context.Context, err error) int {
+	if resp := goa.ContextResponse(ctx); resp != nil && resp.Status != 0 {
+		return resp.Status
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return http.StatusOK
+}
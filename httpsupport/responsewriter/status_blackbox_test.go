@@ -0,0 +1,40 @@
+package responsewriter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/httpsupport/responsewriter"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/goadesign/goa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoaStatus(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	newCtx := func() context.Context {
+		return goa.NewContext(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	}
+
+	t.Run("status of the response", func(t *testing.T) {
+		ctx := newCtx()
+		goa.ContextResponse(ctx).WriteHeader(http.StatusNotFound)
+		assert.Equal(t, http.StatusNotFound, responsewriter.GoaStatus(ctx, errors.New("not found")))
+	})
+
+	t.Run("unhandled error", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, responsewriter.GoaStatus(newCtx(), errors.New("failure")))
+	})
+
+	t.Run("no status and no error", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, responsewriter.GoaStatus(newCtx(), nil))
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, responsewriter.GoaStatus(context.Background(), errors.New("failure")))
+	})
+}
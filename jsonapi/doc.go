@@ -0,0 +1,4 @@
+// Package jsonapi provides the goa middleware and helper functions to convert
+// errors into JSON-API error responses (see http://jsonapi.org/format/#errors),
+// or into RFC 7807 problem details if requested by the client.
+package jsonapi
@@ -0,0 +1,126 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/sentry"
+
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+)
+
+const (
+	// ErrorMediaIdentifier type for errors returned by the JSON API error handler middleware
+	ErrorMediaIdentifier = "application/vnd.api+json"
+	// RequestIDHeader the name of the response header containing the ID of the request
+	// for which an internal error occurred
+	RequestIDHeader = requestid.Header
+	// requestIDMetaKey the key of the request ID in the `meta` object of the JSON-API errors
+	requestIDMetaKey = "request_id"
+)
+
+// ErrorHandlerOption an option to configure the ErrorHandler
+type ErrorHandlerOption func(config *errorHandlerConfig)
+
+type errorHandlerConfig struct {
+	catalog *MessageCatalog
+}
+
+// WithMessageCatalog configures the ErrorHandler to localize the details of the errors
+// with the templates of the given catalog
+func WithMessageCatalog(catalog *MessageCatalog) ErrorHandlerOption {
+	return func(config *errorHandlerConfig) {
+		config.catalog = catalog
+	}
+}
+
+// ErrorHandler turns a Go error into an JSONAPI HTTP response. It should be placed in the middleware chain
+// below the logger middleware so the logger properly logs the HTTP response. ErrorHandler
+// understands instances of goa.ServiceError and returns the status and response body embodied in
+// them, it turns other Go error types into a 500 internal error response.
+// If verbose is false the details of internal errors is not included in HTTP responses.
+// If you use github.com/pkg/errors then wrapping the error will allow a trace to be printed to the logs
+// For 5xx responses, the request ID (as returned by requestid.FromContext, or a new one if none was set)
+// is included in the `meta` of the JSON-API errors and in the `X-Request-ID` response header,
+// and the error is reported to Sentry with the same request ID.
+// The response is an RFC 7807 problem details document instead of JSON-API errors if the client
+// prefers the `application/problem+json` media type in the `Accept` request header.
+// Use the WithMessageCatalog option to localize the error details based on the `Accept-Language` request header.
+func ErrorHandler(service *goa.Service, verbose bool, options ...ErrorHandlerOption) goa.Middleware {
+	config := errorHandlerConfig{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			e := h(ctx, rw, req)
+			if e == nil {
+				return nil
+			}
+			cause := errs.Cause(e)
+			respBody, status := ErrorToJSONAPIErrors(ctx, e)
+			respErr := e
+			if err, ok := cause.(goa.ServiceError); ok {
+				status = err.ResponseStatus()
+				goa.ContextResponse(ctx).ErrorCode = err.Token()
+			}
+			if status >= 500 && status < 600 {
+				reqID := requestid.FromContext(ctx)
+				if reqID == "" {
+					reqID = requestid.New()
+					// store the request ID so that the log entries and the Sentry report use it as well
+					ctx = requestid.NewContext(ctx, reqID)
+				}
+				log.Error(ctx, map[string]interface{}{
+					"msg": respBody,
+					"err": fmt.Sprintf("%+v", e),
+				}, "uncaught error detected in ErrorHandler")
+				sentry.Sentry().CaptureError(ctx, e)
+
+				if !verbose {
+					msg := errors.NewInternalError(ctx, errs.Errorf("%s [%s]", http.StatusText(http.StatusInternalServerError), reqID))
+					respBody, status = ErrorToJSONAPIErrors(ctx, msg)
+					respErr = msg
+					// Preserve the ID of the original error as that's what gets logged, the client
+					// received error ID must match the original
+					if origErrID := goa.ContextResponse(ctx).ErrorCode; origErrID != "" {
+						respBody.Errors[0].ID = &origErrID
+					}
+				}
+				setRequestID(respBody, reqID)
+				rw.Header().Set(RequestIDHeader, reqID)
+			}
+			if config.catalog != nil {
+				if lang := config.catalog.LocalizeErrors(req.Header.Get("Accept-Language"), respBody, respErr); lang != "" {
+					rw.Header().Set("Content-Language", lang)
+				}
+			}
+			if NegotiateErrorMediaType(req.Header.Get("Accept")) == ProblemMediaIdentifier {
+				rw.Header().Set("Content-Type", ProblemMediaIdentifier)
+				return service.Send(ctx, status, toProblemDetails(ctx, *respBody.Errors[0], status))
+			}
+			rw.Header().Set("Content-Type", ErrorMediaIdentifier)
+			return service.Send(ctx, status, respBody)
+		}
+	}
+}
+
+// setRequestID sets the given request ID in the `meta` of all the given errors,
+// as well as their `id` if it was not set yet.
+func setRequestID(jerrs *JSONAPIErrors, reqID string) {
+	for _, e := range jerrs.Errors {
+		if e.ID == nil {
+			id := reqID
+			e.ID = &id
+		}
+		if e.Meta == nil {
+			e.Meta = map[string]interface{}{}
+		}
+		e.Meta[requestIDMetaKey] = reqID
+	}
+}
@@ -0,0 +1,112 @@
+package jsonapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/jsonapi"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	service := goa.New("test")
+	service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+
+	serveWithRequestID := func(t *testing.T, verbose bool, handlerErr error, reqID string) (*httptest.ResponseRecorder, jsonapi.JSONAPIErrors) {
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/foo", nil)
+		require.NoError(t, err)
+		ctx := goa.NewContext(context.Background(), rw, req, url.Values{})
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return handlerErr
+		}
+		handler := jsonapi.ErrorHandler(service, verbose)(h)
+		if reqID != "" {
+			req.Header.Set(middleware.RequestIDHeader, reqID)
+			handler = middleware.RequestID()(handler)
+		}
+		err = handler(ctx, rw, req)
+		require.NoError(t, err)
+		var body jsonapi.JSONAPIErrors
+		if handlerErr != nil {
+			err = json.Unmarshal(rw.Body.Bytes(), &body)
+			require.NoError(t, err)
+		}
+		return rw, body
+	}
+	serve := func(t *testing.T, verbose bool, handlerErr error) (*httptest.ResponseRecorder, jsonapi.JSONAPIErrors) {
+		return serveWithRequestID(t, verbose, handlerErr, "")
+	}
+
+	t.Run("no error", func(t *testing.T) {
+		// when
+		rw, _ := serve(t, false, nil)
+		// then
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Empty(t, rw.Body.String())
+	})
+
+	t.Run("not found error", func(t *testing.T) {
+		// when
+		rw, body := serve(t, false, errors.NewNotFoundError("foo", "bar"))
+		// then
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		assert.Equal(t, jsonapi.ErrorMediaIdentifier, rw.Header().Get("Content-Type"))
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, jsonapi.ErrorCodeNotFound, *body.Errors[0].Code)
+		assert.Equal(t, "foo with id 'bar' not found", body.Errors[0].Detail)
+		assert.Empty(t, rw.Header().Get(jsonapi.RequestIDHeader))
+	})
+
+	t.Run("internal error in verbose mode", func(t *testing.T) {
+		// when
+		rw, body := serve(t, true, errs.New("database is on fire"))
+		// then
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, "database is on fire", body.Errors[0].Detail)
+	})
+
+	t.Run("internal error in non-verbose mode", func(t *testing.T) {
+		// when
+		rw, body := serve(t, false, errs.New("database is on fire"))
+		// then
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Equal(t, jsonapi.ErrorMediaIdentifier, rw.Header().Get("Content-Type"))
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, jsonapi.ErrorCodeInternalError, *body.Errors[0].Code)
+		assert.NotContains(t, body.Errors[0].Detail, "database is on fire")
+		// a request ID was generated and is returned to the client
+		reqID := rw.Header().Get(jsonapi.RequestIDHeader)
+		require.NotEmpty(t, reqID)
+		require.NotNil(t, body.Errors[0].ID)
+		assert.Equal(t, reqID, *body.Errors[0].ID)
+		assert.Equal(t, reqID, body.Errors[0].Meta["request_id"])
+		assert.Contains(t, body.Errors[0].Detail, reqID)
+	})
+
+	t.Run("internal error with request ID", func(t *testing.T) {
+		// when
+		rw, body := serveWithRequestID(t, false, errs.New("database is on fire"), "my-request-id")
+		// then
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Equal(t, "my-request-id", rw.Header().Get(jsonapi.RequestIDHeader))
+		require.Len(t, body.Errors, 1)
+		require.NotNil(t, body.Errors[0].ID)
+		assert.Equal(t, "my-request-id", *body.Errors[0].ID)
+		assert.Equal(t, "my-request-id", body.Errors[0].Meta["request_id"])
+		assert.Equal(t, "Internal Server Error [my-request-id]", body.Errors[0].Detail)
+	})
+}
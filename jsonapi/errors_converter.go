@@ -8,10 +8,12 @@ import (
 
 	"github.com/fabric8-services/fabric8-common/errors"
 	"github.com/fabric8-services/fabric8-common/log"
+
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
 )
 
+// Error codes used in the `code` member of the JSON-API errors
 const (
 	ErrorCodeNotFound          = "not_found"
 	ErrorCodeBadParameter      = "bad_parameter"
@@ -27,7 +29,7 @@ const (
 
 // ErrorToJSONAPIError returns the JSONAPI representation
 // of an error and the HTTP status code that will be associated with it.
-// This function knows about the models package and the errors from there
+// This function knows about the errors from the `errors` package
 // as well as goa error classes.
 func ErrorToJSONAPIError(ctx context.Context, err error) (JSONAPIError, int) {
 	cause := errs.Cause(err)
@@ -73,8 +75,6 @@ func ErrorToJSONAPIError(ctx context.Context, err error) (JSONAPIError, int) {
 		code = ErrorCodeUnknownError
 		title = "Unknown error"
 		statusCode = http.StatusInternalServerError
-
-		cause := errs.Cause(err)
 		if err, ok := cause.(goa.ServiceError); ok {
 			statusCode = err.ResponseStatus()
 			idStr := err.Token()
@@ -98,7 +98,7 @@ func ErrorToJSONAPIError(ctx context.Context, err error) (JSONAPIError, int) {
 }
 
 // ErrorToJSONAPIErrors is a convenience function if you
-// just want to return one error from the models package as a JSONAPI errors
+// just want to return one error from the `errors` package as a JSONAPI errors
 // array.
 func ErrorToJSONAPIErrors(ctx context.Context, err error) (*JSONAPIErrors, int) {
 	jerr, httpStatusCode := ErrorToJSONAPIError(ctx, err)
@@ -106,68 +106,3 @@ func ErrorToJSONAPIErrors(ctx context.Context, err error) (*JSONAPIErrors, int)
 	jerrors.Errors = append(jerrors.Errors, &jerr)
 	return &jerrors, httpStatusCode
 }
-
-// BadRequest represent a Context that can return a BadRequest HTTP status
-type BadRequestContext interface {
-	context.Context
-	BadRequest(*JSONAPIErrors) error
-}
-
-// InternalServerErrorContext represent a Context that can return a InternalServerError HTTP status
-type InternalServerErrorContext interface {
-	context.Context
-	InternalServerError(*JSONAPIErrors) error
-}
-
-// NotFound represent a Context that can return a NotFound HTTP status
-type NotFoundContext interface {
-	context.Context
-	NotFound(*JSONAPIErrors) error
-}
-
-// Unauthorized represent a Context that can return a Unauthorized HTTP status
-type UnauthorizedContext interface {
-	context.Context
-	Unauthorized(*JSONAPIErrors) error
-}
-
-// Forbidden represent a Context that can return a Unauthorized HTTP status
-type ForbiddenContext interface {
-	context.Context
-	Forbidden(*JSONAPIErrors) error
-}
-
-// Conflict represent a Context that can return a Conflict HTTP status
-type ConflictContext interface {
-	context.Context
-	Conflict(*JSONAPIErrors) error
-}
-
-// JSONErrorResponse auto maps the provided error to the correct response type
-// If all else fails, InternalServerError is returned
-func JSONErrorResponse(ctx InternalServerErrorContext, err error) error {
-	jsonErr, status := ErrorToJSONAPIErrors(ctx, err)
-	switch status {
-	case http.StatusBadRequest:
-		if ctx, ok := ctx.(BadRequestContext); ok {
-			return ctx.BadRequest(jsonErr)
-		}
-	case http.StatusNotFound:
-		if ctx, ok := ctx.(NotFoundContext); ok {
-			return ctx.NotFound(jsonErr)
-		}
-	case http.StatusUnauthorized:
-		if ctx, ok := ctx.(UnauthorizedContext); ok {
-			return ctx.Unauthorized(jsonErr)
-		}
-	case http.StatusForbidden:
-		if ctx, ok := ctx.(ForbiddenContext); ok {
-			return ctx.Forbidden(jsonErr)
-		}
-	case http.StatusConflict:
-		if ctx, ok := ctx.(ConflictContext); ok {
-			return ctx.Conflict(jsonErr)
-		}
-	}
-	return ctx.InternalServerError(jsonErr)
-}
\ No newline at end of file
@@ -0,0 +1,76 @@
+package jsonapi_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/jsonapi"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorToJSONAPIError(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	ctx := context.Background()
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"not found error", errors.NewNotFoundError("foo", "bar"), http.StatusNotFound, jsonapi.ErrorCodeNotFound},
+		{"conversion error", errors.NewConversionError("foo"), http.StatusBadRequest, jsonapi.ErrorCodeConversionError},
+		{"bad parameter error", errors.NewBadParameterError("foo", "bar"), http.StatusBadRequest, jsonapi.ErrorCodeBadParameter},
+		{"version conflict error", errors.NewVersionConflictError("foo"), http.StatusConflict, jsonapi.ErrorCodeVersionConflict},
+		{"data conflict error", errors.NewDataConflictError("foo"), http.StatusConflict, jsonapi.ErrorCodeDataConflict},
+		{"internal server error", errors.NewInternalError(ctx, errs.New("foo")), http.StatusInternalServerError, jsonapi.ErrorCodeInternalError},
+		{"unauthorized error", errors.NewUnauthorizedError("foo"), http.StatusUnauthorized, jsonapi.ErrorCodeUnauthorizedError},
+		{"forbidden error", errors.NewForbiddenError("foo"), http.StatusForbidden, jsonapi.ErrorCodeForbiddenError},
+		{"wrapped error", errs.Wrap(errors.NewNotFoundError("foo", "bar"), "wrapped"), http.StatusNotFound, jsonapi.ErrorCodeNotFound},
+		{"unspecified error", fmt.Errorf("foobar"), http.StatusInternalServerError, jsonapi.ErrorCodeUnknownError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			jerr, httpStatus := jsonapi.ErrorToJSONAPIError(ctx, tc.err)
+			// then
+			require.Equal(t, tc.expectedStatus, httpStatus)
+			require.NotNil(t, jerr.Code)
+			require.NotNil(t, jerr.Status)
+			assert.Equal(t, tc.expectedCode, *jerr.Code)
+			assert.Equal(t, strconv.Itoa(httpStatus), *jerr.Status)
+		})
+	}
+
+	t.Run("goa error", func(t *testing.T) {
+		// when
+		jerr, httpStatus := jsonapi.ErrorToJSONAPIError(ctx, goa.ErrBadRequest("invalid payload"))
+		// then
+		require.Equal(t, http.StatusBadRequest, httpStatus)
+		require.NotNil(t, jerr.Code)
+		require.NotNil(t, jerr.ID)
+		assert.Equal(t, "bad_request", *jerr.Code)
+		assert.Equal(t, "invalid payload", jerr.Detail)
+	})
+}
+
+func TestErrorToJSONAPIErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// when
+	jerrs, httpStatus := jsonapi.ErrorToJSONAPIErrors(context.Background(), errors.NewNotFoundError("foo", "bar"))
+	// then
+	require.Equal(t, http.StatusNotFound, httpStatus)
+	require.Len(t, jerrs.Errors, 1)
+	assert.Equal(t, "foo with id 'bar' not found", jerrs.Errors[0].Detail)
+}
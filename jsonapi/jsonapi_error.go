@@ -0,0 +1,73 @@
+package jsonapi
+
+import (
+	"fmt"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// JSONAPIError is the JSON-API representation of a single error.
+// See http://jsonapi.org/format/#error-objects
+type JSONAPIError struct {
+	// an application-specific error code, expressed as a string value.
+	Code *string `form:"code,omitempty" json:"code,omitempty" yaml:"code,omitempty" xml:"code,omitempty"`
+	// a human-readable explanation specific to this occurrence of the problem.
+	Detail string `form:"detail" json:"detail" yaml:"detail" xml:"detail"`
+	// a unique identifier for this particular occurrence of the problem.
+	ID *string `form:"id,omitempty" json:"id,omitempty" yaml:"id,omitempty" xml:"id,omitempty"`
+	// a links object containing links that lead to further details about this particular occurrence of the problem.
+	Links map[string]*JSONAPILink `form:"links,omitempty" json:"links,omitempty" yaml:"links,omitempty" xml:"links,omitempty"`
+	// a meta object containing non-standard meta-information about the error
+	Meta map[string]interface{} `form:"meta,omitempty" json:"meta,omitempty" yaml:"meta,omitempty" xml:"meta,omitempty"`
+	// an object containing references to the source of the error.
+	Source map[string]interface{} `form:"source,omitempty" json:"source,omitempty" yaml:"source,omitempty" xml:"source,omitempty"`
+	// the HTTP status code applicable to this problem, expressed as a string value.
+	Status *string `form:"status,omitempty" json:"status,omitempty" yaml:"status,omitempty" xml:"status,omitempty"`
+	// a short, human-readable summary of the problem that SHOULD NOT change from
+	// occurrence to occurrence of the problem, except for purposes of localization.
+	Title *string `form:"title,omitempty" json:"title,omitempty" yaml:"title,omitempty" xml:"title,omitempty"`
+}
+
+// JSONAPILink is a link as defined in http://jsonapi.org/format/#document-links
+type JSONAPILink struct {
+	// a string containing the link's URL.
+	Href *string `form:"href,omitempty" json:"href,omitempty" yaml:"href,omitempty" xml:"href,omitempty"`
+	// a meta object containing non-standard meta-information about the link.
+	Meta map[string]interface{} `form:"meta,omitempty" json:"meta,omitempty" yaml:"meta,omitempty" xml:"meta,omitempty"`
+}
+
+// JSONAPIErrors is the JSON-API document containing one or more errors.
+type JSONAPIErrors struct {
+	Errors []*JSONAPIError `form:"errors" json:"errors" yaml:"errors" xml:"errors"`
+}
+
+// String implements the Stringer interface for JSONAPIErrors
+func (mt JSONAPIErrors) String() string {
+	if mt.Errors == nil {
+		return ""
+	}
+	res := fmt.Sprintf("%d JSONAPI Error(s):\n", len(mt.Errors))
+	for i, e := range mt.Errors {
+		res += fmt.Sprintf("[ERROR No. %3d]: %s\n", i, e)
+	}
+	return res
+}
+
+// String implements the Stringer interface for a JSONAPIError
+func (ut JSONAPIError) String() string {
+	return fmt.Sprintf(`Code:    %[1]s
+		Detail:  %[2]s
+		ID:      %[3]s
+		Links:   %[4]s
+		Meta:    %[5]s
+		Source:  %[6]s
+		Status:  %[7]s
+		Title:   %[8]s`, spew.Sdump(ut.Code),
+		spew.Sdump(ut.Detail),
+		spew.Sdump(ut.ID),
+		spew.Sdump(ut.Links),
+		spew.Sdump(ut.Meta),
+		spew.Sdump(ut.Source),
+		spew.Sdump(ut.Status),
+		spew.Sdump(ut.Title))
+}
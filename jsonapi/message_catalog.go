@@ -0,0 +1,171 @@
+package jsonapi
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+
+	errs "github.com/pkg/errors"
+)
+
+// MessageCatalog contains the localized templates of the error details, keyed by
+// error code (e.g. `ErrorCodeNotFound`) and by language tag (e.g. `fr` or `pt-BR`).
+// The templates are parsed with the `text/template` package and executed with the
+// MessageData of the error.
+type MessageCatalog struct {
+	lock      sync.RWMutex
+	templates map[string]map[string]*template.Template
+}
+
+// MessageData the data that can be used in the templates of a MessageCatalog
+type MessageData struct {
+	// Code the code of the error (e.g. `not_found`)
+	Code string
+	// Message the default (English) message of the error
+	Message string
+	// Parameter the name of the parameter of a BadParameterError
+	Parameter string
+	// Value the value of the parameter of a BadParameterError
+	Value interface{}
+	// ExpectedValue the expected value of the parameter of a BadParameterError, if HasExpectedValue is true
+	ExpectedValue interface{}
+	// HasExpectedValue true if the expected value of the parameter of a BadParameterError was set
+	HasExpectedValue bool
+	// Entity the name of the entity of a NotFoundError
+	Entity string
+	// ID the ID of the entity of a NotFoundError
+	ID string
+}
+
+// NewMessageCatalog returns a new, empty message catalog
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{
+		templates: map[string]map[string]*template.Template{},
+	}
+}
+
+// Add parses and adds the template of the error details for the given error code and language
+func (c *MessageCatalog) Add(code, lang, text string) error {
+	tmpl, err := template.New(code + "/" + lang).Parse(text)
+	if err != nil {
+		return errs.Wrapf(err, "invalid template for error code '%s' and language '%s'", code, lang)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, found := c.templates[code]; !found {
+		c.templates[code] = map[string]*template.Template{}
+	}
+	c.templates[code][strings.ToLower(lang)] = tmpl
+	return nil
+}
+
+// Localize returns the details of the error described by the given data, in the language
+// which best matches the given `Accept-Language` header value. The second result is the
+// language that was used, and the last one is false if no template was found for the error code
+// and any of the accepted languages (or if the template execution failed).
+func (c *MessageCatalog) Localize(acceptLanguage string, data MessageData) (string, string, bool) {
+	c.lock.RLock()
+	templates, found := c.templates[data.Code]
+	c.lock.RUnlock()
+	if !found {
+		return "", "", false
+	}
+	for _, lang := range parseAcceptLanguage(acceptLanguage) {
+		tmpl, found := templates[lang]
+		if !found {
+			// fall back to the base language (e.g. `fr` for `fr-CH`)
+			lang = strings.SplitN(lang, "-", 2)[0]
+			if tmpl, found = templates[lang]; !found {
+				continue
+			}
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, data); err != nil {
+			return "", "", false
+		}
+		return buf.String(), lang, true
+	}
+	return "", "", false
+}
+
+// LocalizeErrors replaces the details of the given JSON-API errors with their localized
+// versions, in the language which best matches the given `Accept-Language` header value.
+// The given error is the one from which the JSON-API errors were converted.
+// Returns the language that was used, or an empty string if no error was localized.
+func (c *MessageCatalog) LocalizeErrors(acceptLanguage string, jerrs *JSONAPIErrors, err error) string {
+	var result string
+	for _, jerr := range jerrs.Errors {
+		if jerr.Code == nil {
+			continue
+		}
+		data := newMessageData(*jerr.Code, jerr.Detail, err)
+		if detail, lang, ok := c.Localize(acceptLanguage, data); ok {
+			jerr.Detail = detail
+			result = lang
+		}
+	}
+	return result
+}
+
+// newMessageData returns the data of the given error to execute the message templates with
+func newMessageData(code, message string, err error) MessageData {
+	data := MessageData{
+		Code:    code,
+		Message: message,
+	}
+	switch e := errs.Cause(err).(type) {
+	case errors.BadParameterError:
+		data.Parameter = e.Parameter()
+		data.Value = e.Value()
+		data.ExpectedValue, data.HasExpectedValue = e.ExpectedValue()
+	case errors.NotFoundError:
+		data.Entity = e.Entity()
+		data.ID = e.ID
+	}
+	return data
+}
+
+// parseAcceptLanguage returns the lower-cased language tags of the given `Accept-Language`
+// header value, ordered by decreasing quality. The wildcard and the unacceptable languages
+// (i.e., with `q=0`) are ignored.
+func parseAcceptLanguage(acceptLanguage string) []string {
+	type weightedLang struct {
+		lang string
+		q    float64
+	}
+	langs := []weightedLang{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		elements := strings.Split(part, ";")
+		lang := strings.ToLower(strings.TrimSpace(elements[0]))
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range elements[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err != nil {
+					v = 0
+				}
+				q = v
+			}
+		}
+		if q > 0 {
+			langs = append(langs, weightedLang{lang: lang, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+	result := make([]string, len(langs))
+	for i, l := range langs {
+		result[i] = l.lang
+	}
+	return result
+}
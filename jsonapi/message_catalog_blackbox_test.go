@@ -0,0 +1,94 @@
+package jsonapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/jsonapi"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/goadesign/goa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCatalog(t *testing.T) *jsonapi.MessageCatalog {
+	catalog := jsonapi.NewMessageCatalog()
+	require.NoError(t, catalog.Add(jsonapi.ErrorCodeNotFound, "fr", "{{.Entity}} avec l'id '{{.ID}}' introuvable"))
+	require.NoError(t, catalog.Add(jsonapi.ErrorCodeNotFound, "de", "{{.Entity}} mit der ID '{{.ID}}' nicht gefunden"))
+	require.NoError(t, catalog.Add(jsonapi.ErrorCodeBadParameter, "pt-BR", "Valor inválido para o parâmetro '{{.Parameter}}': '{{.Value}}'{{if .HasExpectedValue}} (esperado: '{{.ExpectedValue}}'){{end}}"))
+	return catalog
+}
+
+func TestMessageCatalog(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	catalog := newTestCatalog(t)
+
+	t.Run("invalid template", func(t *testing.T) {
+		err := jsonapi.NewMessageCatalog().Add(jsonapi.ErrorCodeNotFound, "fr", "{{.Entity")
+		require.Error(t, err)
+	})
+
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		err            error
+		expectedDetail string
+		expectedLang   string
+	}{
+		{"exact match", "fr", errors.NewNotFoundError("space", "foo"), "space avec l'id 'foo' introuvable", "fr"},
+		{"base language match", "fr-CH", errors.NewNotFoundError("space", "foo"), "space avec l'id 'foo' introuvable", "fr"},
+		{"quality ordering", "fr;q=0.5, de", errors.NewNotFoundError("space", "foo"), "space mit der ID 'foo' nicht gefunden", "de"},
+		{"first supported language", "es, it;q=0.9, de;q=0.8", errors.NewNotFoundError("space", "foo"), "space mit der ID 'foo' nicht gefunden", "de"},
+		{"unacceptable language", "de;q=0, fr;q=0.1", errors.NewNotFoundError("space", "foo"), "space avec l'id 'foo' introuvable", "fr"},
+		{"case insensitive match", "PT-br", errors.NewBadParameterError("name", "foo"), "Valor inválido para o parâmetro 'name': 'foo'", "pt-br"},
+		{"with expected value", "pt-BR", errors.NewBadParameterError("name", "foo").Expected("bar"), "Valor inválido para o parâmetro 'name': 'foo' (esperado: 'bar')", "pt-br"},
+		{"no matching language", "es, *", errors.NewNotFoundError("space", "foo"), "space with id 'foo' not found", ""},
+		{"no accept-language", "", errors.NewNotFoundError("space", "foo"), "space with id 'foo' not found", ""},
+		{"no template for code", "fr", errors.NewForbiddenError("not allowed"), "not allowed", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			jerrs, _ := jsonapi.ErrorToJSONAPIErrors(context.Background(), tc.err)
+			// when
+			lang := catalog.LocalizeErrors(tc.acceptLanguage, jerrs, tc.err)
+			// then
+			assert.Equal(t, tc.expectedLang, lang)
+			assert.Equal(t, tc.expectedDetail, jerrs.Errors[0].Detail)
+		})
+	}
+}
+
+func TestErrorHandlerWithMessageCatalog(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	service := goa.New("test")
+	service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/foo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Language", "fr-FR, fr;q=0.9, en;q=0.8")
+	ctx := goa.NewContext(context.Background(), rw, req, url.Values{})
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		return errors.NewNotFoundError("space", "foo")
+	}
+	// when
+	err = jsonapi.ErrorHandler(service, false, jsonapi.WithMessageCatalog(newTestCatalog(t)))(h)(ctx, rw, req)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.Equal(t, "fr", rw.Header().Get("Content-Language"))
+	var body jsonapi.JSONAPIErrors
+	err = json.Unmarshal(rw.Body.Bytes(), &body)
+	require.NoError(t, err)
+	require.Len(t, body.Errors, 1)
+	assert.Equal(t, jsonapi.ErrorCodeNotFound, *body.Errors[0].Code)
+	assert.Equal(t, "space avec l'id 'foo' introuvable", body.Errors[0].Detail)
+}
@@ -0,0 +1,156 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+const (
+	// ProblemMediaIdentifier type for errors returned as RFC 7807 problem details
+	ProblemMediaIdentifier = "application/problem+json"
+	// ProblemTypePrefix the prefix of the `type` member of the problem details,
+	// which is followed by the error code
+	ProblemTypePrefix = "urn:fabric8:error:"
+)
+
+// ProblemDetails is the representation of an error as specified in RFC 7807
+// (see https://tools.ietf.org/html/rfc7807)
+type ProblemDetails struct {
+	// a URI reference that identifies the problem type.
+	Type string `json:"type"`
+	// a short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// the HTTP status code generated by the origin server for this occurrence of the problem.
+	Status int `json:"status,omitempty"`
+	// a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// a URI reference that identifies the specific occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// additional members of the problem details (e.g. `code`, `request_id`, etc.)
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Extension members are
+// written at the top level of the JSON object, but cannot override the standard members.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	result := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		result[k] = v
+	}
+	result["type"] = p.Type
+	if p.Title != "" {
+		result["title"] = p.Title
+	}
+	if p.Status != 0 {
+		result["status"] = p.Status
+	}
+	if p.Detail != "" {
+		result["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		result["instance"] = p.Instance
+	}
+	return json.Marshal(result)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Non-standard members
+// are collected in the Extensions.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	type standardMembers ProblemDetails // prevents recursive calls to UnmarshalJSON
+	var s standardMembers
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	*p = ProblemDetails(s)
+	for _, k := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(all, k)
+	}
+	if len(all) > 0 {
+		p.Extensions = all
+	}
+	return nil
+}
+
+// ErrorToProblemDetails returns the RFC 7807 representation of an error and the
+// HTTP status code that will be associated with it. The error is mapped in the same
+// way as in ErrorToJSONAPIError.
+func ErrorToProblemDetails(ctx context.Context, err error) (*ProblemDetails, int) {
+	jerr, status := ErrorToJSONAPIError(ctx, err)
+	return toProblemDetails(ctx, jerr, status), status
+}
+
+// toProblemDetails converts the given JSON-API error into problem details
+func toProblemDetails(ctx context.Context, jerr JSONAPIError, status int) *ProblemDetails {
+	p := ProblemDetails{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     jerr.Detail,
+		Extensions: map[string]interface{}{},
+	}
+	if jerr.Code != nil {
+		p.Type = ProblemTypePrefix + *jerr.Code
+		p.Extensions["code"] = *jerr.Code
+	}
+	if jerr.Title != nil {
+		p.Title = *jerr.Title
+	}
+	if jerr.ID != nil {
+		p.Extensions["id"] = *jerr.ID
+	}
+	for k, v := range jerr.Meta {
+		p.Extensions[k] = v
+	}
+	if ctx != nil {
+		if req := goa.ContextRequest(ctx); req != nil && req.URL != nil {
+			p.Instance = req.URL.RequestURI()
+		}
+	}
+	return &p
+}
+
+// NegotiateErrorMediaType returns the media type to use in an error response,
+// based on the given value of the `Accept` request header: ProblemMediaIdentifier if it
+// is preferred over ErrorMediaIdentifier, ErrorMediaIdentifier otherwise.
+func NegotiateErrorMediaType(accept string) string {
+	var jsonapiQ, problemQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case ErrorMediaIdentifier:
+			jsonapiQ = maxQ(jsonapiQ, q)
+		case ProblemMediaIdentifier:
+			problemQ = maxQ(problemQ, q)
+		}
+	}
+	if problemQ > jsonapiQ {
+		return ProblemMediaIdentifier
+	}
+	return ErrorMediaIdentifier
+}
+
+func maxQ(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
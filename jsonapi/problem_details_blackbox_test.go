@@ -0,0 +1,138 @@
+package jsonapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/jsonapi"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateErrorMediaType(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	testCases := []struct {
+		accept   string
+		expected string
+	}{
+		{"", jsonapi.ErrorMediaIdentifier},
+		{"*/*", jsonapi.ErrorMediaIdentifier},
+		{"application/json", jsonapi.ErrorMediaIdentifier},
+		{"application/vnd.api+json", jsonapi.ErrorMediaIdentifier},
+		{"application/problem+json", jsonapi.ProblemMediaIdentifier},
+		{"application/json, application/problem+json", jsonapi.ProblemMediaIdentifier},
+		{"application/vnd.api+json, application/problem+json", jsonapi.ErrorMediaIdentifier},
+		{"application/vnd.api+json;q=0.5, application/problem+json", jsonapi.ProblemMediaIdentifier},
+		{"application/vnd.api+json, application/problem+json;q=0.9", jsonapi.ErrorMediaIdentifier},
+		{"application/problem+json;q=invalid", jsonapi.ErrorMediaIdentifier},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			assert.Equal(t, tc.expected, jsonapi.NegotiateErrorMediaType(tc.accept))
+		})
+	}
+}
+
+func TestErrorToProblemDetails(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("not found error", func(t *testing.T) {
+		// when
+		p, status := jsonapi.ErrorToProblemDetails(context.Background(), errors.NewNotFoundError("foo", "bar"))
+		// then
+		require.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, "urn:fabric8:error:not_found", p.Type)
+		assert.Equal(t, "Not found error", p.Title)
+		assert.Equal(t, http.StatusNotFound, p.Status)
+		assert.Equal(t, "foo with id 'bar' not found", p.Detail)
+		assert.Equal(t, jsonapi.ErrorCodeNotFound, p.Extensions["code"])
+		assert.Empty(t, p.Instance)
+	})
+
+	t.Run("with request in context", func(t *testing.T) {
+		// given
+		req, err := http.NewRequest("GET", "http://localhost/api/foo?bar=baz", nil)
+		require.NoError(t, err)
+		ctx := goa.NewContext(context.Background(), httptest.NewRecorder(), req, url.Values{})
+		// when
+		p, status := jsonapi.ErrorToProblemDetails(ctx, errors.NewForbiddenError("not allowed"))
+		// then
+		require.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "/api/foo?bar=baz", p.Instance)
+	})
+}
+
+func TestProblemDetailsJSON(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	p := jsonapi.ProblemDetails{
+		Type:   "urn:fabric8:error:bad_parameter",
+		Title:  "Bad parameter error",
+		Status: http.StatusBadRequest,
+		Detail: "Bad value for parameter 'foo': 'bar'",
+		Extensions: map[string]interface{}{
+			"code": "bad_parameter",
+			"type": "cannot override standard member",
+		},
+	}
+	// when
+	data, err := json.Marshal(p)
+	// then
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "urn:fabric8:error:bad_parameter",
+		"title": "Bad parameter error",
+		"status": 400,
+		"detail": "Bad value for parameter 'foo': 'bar'",
+		"code": "bad_parameter"
+	}`, string(data))
+	// when
+	var result jsonapi.ProblemDetails
+	err = json.Unmarshal(data, &result)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, p.Type, result.Type)
+	assert.Equal(t, p.Status, result.Status)
+	assert.Equal(t, map[string]interface{}{"code": "bad_parameter"}, result.Extensions)
+}
+
+func TestErrorHandlerWithProblemDetails(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	service := goa.New("test")
+	service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/foo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/problem+json")
+	ctx := goa.NewContext(context.Background(), rw, req, url.Values{})
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		return errs.New("database is on fire")
+	}
+	// when
+	err = jsonapi.ErrorHandler(service, false)(h)(ctx, rw, req)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	assert.Equal(t, jsonapi.ProblemMediaIdentifier, rw.Header().Get("Content-Type"))
+	var p jsonapi.ProblemDetails
+	err = json.Unmarshal(rw.Body.Bytes(), &p)
+	require.NoError(t, err)
+	assert.Equal(t, "urn:fabric8:error:internal_error", p.Type)
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, "/foo", p.Instance)
+	assert.Equal(t, rw.Header().Get(jsonapi.RequestIDHeader), p.Extensions["request_id"])
+}
@@ -0,0 +1,271 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/httpsupport/responsewriter"
+
+	"github.com/goadesign/goa"
+)
+
+// AccessLogFormat the format of the access log entries
+type AccessLogFormat string
+
+const (
+	// AccessLogDefault the access log entries are written with the log backend,
+	// with a stable set of fields (see AccessLogHandler)
+	AccessLogDefault AccessLogFormat = "default"
+	// AccessLogECS the access log entries are written with the log backend,
+	// with fields named after the Elastic Common Schema (ECS)
+	AccessLogECS AccessLogFormat = "ecs"
+	// AccessLogCombined the access log entries are written in the Apache combined log format
+	// to the access log writer (see WithAccessLogWriter)
+	AccessLogCombined AccessLogFormat = "combined"
+	// AccessLogJSON the access log entries are written as JSON objects with the default
+	// set of fields to the access log writer (see WithAccessLogWriter)
+	AccessLogJSON AccessLogFormat = "json"
+)
+
+// AccessLogOption an option to configure the access log middlewares
+type AccessLogOption func(*accessLogConfig)
+
+type accessLogConfig struct {
+	format         AccessLogFormat
+	writer         io.Writer
+	trustedProxies []*net.IPNet
+}
+
+// WithAccessLogFormat sets the format of the access log entries (default: AccessLogDefault)
+func WithAccessLogFormat(format AccessLogFormat) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.format = format
+	}
+}
+
+// WithAccessLogWriter sets the writer for the access log entries in the AccessLogCombined
+// and AccessLogJSON formats (default: os.Stdout)
+func WithAccessLogWriter(w io.Writer) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.writer = w
+	}
+}
+
+// WithTrustedProxies sets the networks of the proxies whose `X-Forwarded-For` header is trusted to
+// obtain the remote IP of the client. By default, no proxy is trusted and the remote IP is the address
+// of the peer.
+func WithTrustedProxies(networks ...*net.IPNet) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.trustedProxies = append(c.trustedProxies, networks...)
+	}
+}
+
+// ParseTrustedProxies parses the given CIDR notations (e.g. "10.0.0.0/8") or single IP addresses
+func ParseTrustedProxies(proxies ...string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: '%s'", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy network: '%s'", p)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func newAccessLogConfig(options ...AccessLogOption) *accessLogConfig {
+	c := &accessLogConfig{
+		format: AccessLogDefault,
+		writer: os.Stdout,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// AccessLogHandler returns a handler which writes one access log entry per request handled by the given handler.
+// In the default format, the entry has the following fields: method, uri, proto, status, bytes, duration (in ms),
+// remote_ip, user_agent, referer and identity_id (if the request is authenticated), along with the request ID.
+func AccessLogHandler(h http.Handler, options ...AccessLogOption) http.Handler {
+	c := newAccessLogConfig(options...)
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		startedAt := time.Now()
+		recorder := responsewriter.NewRecorder(rw)
+		h.ServeHTTP(recorder, req)
+		c.log(req.Context(), req, recorder.Status(), recorder.Length(), time.Since(startedAt), startedAt)
+	})
+}
+
+// AccessLogMiddleware returns a goa middleware which writes one access log entry per request (see AccessLogHandler).
+// It should be mounted after the request ID and JWT middlewares, so the entries include the request and identity IDs.
+func AccessLogMiddleware(options ...AccessLogOption) goa.Middleware {
+	c := newAccessLogConfig(options...)
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			startedAt := time.Now()
+			err := h(ctx, rw, req)
+			length := 0
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				length = resp.Length
+			}
+			status := responsewriter.GoaStatus(ctx, err)
+			c.log(ctx, req, status, length, time.Since(startedAt), startedAt)
+			return err
+		}
+	}
+}
+
+func (c *accessLogConfig) log(ctx context.Context, req *http.Request, status, length int, duration time.Duration, startedAt time.Time) {
+	identityID, _ := extractIdentityID(ctx)
+	remoteIP := c.remoteIP(req)
+	uri := req.RequestURI
+	if uri == "" {
+		uri = req.URL.RequestURI()
+	}
+	switch c.format {
+	case AccessLogCombined:
+		user := "-"
+		if identityID != "" {
+			user = identityID
+		}
+		bytes := "-"
+		if length > 0 {
+			bytes = fmt.Sprintf("%d", length)
+		}
+		line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s %q %q\n",
+			remoteIP, user, startedAt.Format("02/Jan/2006:15:04:05 -0700"),
+			req.Method, uri, req.Proto, status, bytes, req.Referer(), req.UserAgent())
+		if r := CurrentRedactor(); r != nil {
+			line = r.RedactString(line)
+		}
+		io.WriteString(c.writer, line)
+	case AccessLogECS:
+		fields := map[string]interface{}{
+			"http.request.method":       req.Method,
+			"url.original":              uri,
+			"url.path":                  req.URL.Path,
+			"http.version":              strings.TrimPrefix(req.Proto, "HTTP/"),
+			"http.response.status_code": status,
+			"http.response.body.bytes":  length,
+			"event.duration":            duration.Nanoseconds(),
+			"client.ip":                 remoteIP,
+			"user_agent.original":       req.UserAgent(),
+		}
+		if referer := req.Referer(); referer != "" {
+			fields["http.request.referrer"] = referer
+		}
+		if identityID != "" {
+			fields["user.id"] = identityID
+		}
+		if reqID := ExtractRequestID(ctx); reqID != "" {
+			fields["http.request.id"] = reqID
+		}
+		logAccess(ctx, fields)
+	default:
+		fields := map[string]interface{}{
+			"method":        req.Method,
+			"uri":           uri,
+			"proto":         req.Proto,
+			"status":        status,
+			"bytes":         length,
+			"duration":      duration.Seconds() * 1e3,
+			"duration_unit": "ms",
+			"remote_ip":     remoteIP,
+			"user_agent":    req.UserAgent(),
+		}
+		if referer := req.Referer(); referer != "" {
+			fields["referer"] = referer
+		}
+		if identityID != "" {
+			fields["identity_id"] = identityID
+		}
+		if c.format != AccessLogJSON {
+			logAccess(ctx, fields)
+			return
+		}
+		fields["time"] = startedAt.Format(time.RFC3339)
+		fields["req_id"] = ExtractRequestID(ctx)
+		if r := CurrentRedactor(); r != nil {
+			fields = r.RedactFields(fields)
+		}
+		if js, err := json.Marshal(fields); err == nil {
+			c.writer.Write(append(js, '\n'))
+		}
+	}
+}
+
+// logAccess writes the access log entry with the log backend. The access log entries
+// are not subject to sampling, since each one of them relates to a distinct request.
+func logAccess(ctx context.Context, fields map[string]interface{}) {
+	b := CurrentBackend()
+	if !b.Enabled(InfoLevel) || !levels.enabledForPackage(InfoLevel, "") {
+		return
+	}
+	entry := map[string]interface{}{}
+	if ctx != nil {
+		addContextFields(ctx, entry)
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	emit(b, InfoLevel, entry, "access")
+}
+
+// remoteIP returns the IP address of the client. The `X-Forwarded-For` header is only used if
+// the request was received from a trusted proxy, in which case the remote IP is the last address
+// in the header which is not a trusted proxy.
+func (c *accessLogConfig) remoteIP(req *http.Request) string {
+	remote := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !c.isTrusted(remote) {
+		return remote
+	}
+	forwarded := req.Header.Get("X-Forwarded-For")
+	if forwarded == "" {
+		return remote
+	}
+	addresses := strings.Split(forwarded, ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(addresses[i])
+		if !c.isTrusted(addr) || i == 0 {
+			return addr
+		}
+	}
+	return remote
+}
+
+func (c *accessLogConfig) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range c.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/log/logtest"
+
+	"github.com/goadesign/goa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogHandler(t *testing.T) {
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	h := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("hello"))
+	})
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/spaces?access_token=foo", nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		req.Header.Set("User-Agent", "test-agent")
+		req.Header.Set("Referer", "https://openshift.io")
+		req.Header.Set("X-Forwarded-For", "1.2.3.4, 5.6.7.8, 10.0.0.1")
+		return req
+	}
+
+	t.Run("default format", func(t *testing.T) {
+		// given
+		backend.Reset()
+		// when
+		log.AccessLogHandler(h).ServeHTTP(httptest.NewRecorder(), newRequest())
+		// then
+		entries := backend.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "access", entries[0].Message)
+		assert.Equal(t, log.InfoLevel, entries[0].Level)
+		fields := entries[0].Fields
+		assert.Equal(t, http.MethodPost, fields["method"])
+		assert.Equal(t, "/api/spaces?access_token=*****", fields["uri"])
+		assert.Equal(t, "HTTP/1.1", fields["proto"])
+		assert.Equal(t, http.StatusCreated, fields["status"])
+		assert.Equal(t, 5, fields["bytes"])
+		assert.Equal(t, "ms", fields["duration_unit"])
+		assert.IsType(t, float64(0), fields["duration"])
+		// X-Forwarded-For is not trusted by default
+		assert.Equal(t, "10.0.0.2", fields["remote_ip"])
+		assert.Equal(t, "test-agent", fields["user_agent"])
+		assert.Equal(t, "https://openshift.io", fields["referer"])
+	})
+
+	t.Run("trusted proxies", func(t *testing.T) {
+		// given
+		backend.Reset()
+		proxies, err := log.ParseTrustedProxies("10.0.0.0/8", "5.6.7.8")
+		require.NoError(t, err)
+		// when
+		log.AccessLogHandler(h, log.WithTrustedProxies(proxies...)).ServeHTTP(httptest.NewRecorder(), newRequest())
+		// then
+		require.NotNil(t, backend.LastEntry())
+		assert.Equal(t, "1.2.3.4", backend.LastEntry().Fields["remote_ip"])
+	})
+
+	t.Run("ecs format", func(t *testing.T) {
+		// given
+		backend.Reset()
+		// when
+		log.AccessLogHandler(h, log.WithAccessLogFormat(log.AccessLogECS)).ServeHTTP(httptest.NewRecorder(), newRequest())
+		// then
+		require.NotNil(t, backend.LastEntry())
+		fields := backend.LastEntry().Fields
+		assert.Equal(t, http.MethodPost, fields["http.request.method"])
+		assert.Equal(t, "/api/spaces", fields["url.path"])
+		assert.Equal(t, "1.1", fields["http.version"])
+		assert.Equal(t, http.StatusCreated, fields["http.response.status_code"])
+		assert.Equal(t, 5, fields["http.response.body.bytes"])
+		assert.Equal(t, "10.0.0.2", fields["client.ip"])
+		assert.Equal(t, "test-agent", fields["user_agent.original"])
+	})
+
+	t.Run("combined format", func(t *testing.T) {
+		// given
+		buf := &bytes.Buffer{}
+		// when
+		log.AccessLogHandler(h, log.WithAccessLogFormat(log.AccessLogCombined), log.WithAccessLogWriter(buf)).ServeHTTP(httptest.NewRecorder(), newRequest())
+		// then
+		line := buf.String()
+		assert.True(t, strings.HasPrefix(line, "10.0.0.2 - - ["), line)
+		assert.True(t, strings.HasSuffix(line, `] "POST /api/spaces?access_token=***** HTTP/1.1" 201 5 "https://openshift.io" "test-agent"`+"\n"), line)
+	})
+
+	t.Run("json format", func(t *testing.T) {
+		// given
+		buf := &bytes.Buffer{}
+		// when
+		log.AccessLogHandler(h, log.WithAccessLogFormat(log.AccessLogJSON), log.WithAccessLogWriter(buf)).ServeHTTP(httptest.NewRecorder(), newRequest())
+		// then
+		var fields map[string]interface{}
+		err := json.Unmarshal(buf.Bytes(), &fields)
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPost, fields["method"])
+		assert.Equal(t, float64(http.StatusCreated), fields["status"])
+		assert.Contains(t, fields, "time")
+	})
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	rw := httptest.NewRecorder()
+	ctx := goa.NewContext(context.Background(), rw, req, url.Values{})
+
+	t.Run("ok", func(t *testing.T) {
+		// given
+		backend.Reset()
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			resp.Status = http.StatusOK
+			resp.Length = 42
+			return nil
+		}
+		// when
+		err := log.AccessLogMiddleware()(h)(ctx, rw, req)
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, backend.LastEntry())
+		assert.Equal(t, http.StatusOK, backend.LastEntry().Fields["status"])
+		assert.Equal(t, 42, backend.LastEntry().Fields["bytes"])
+		assert.Equal(t, "/api/status", backend.LastEntry().Fields["uri"])
+	})
+
+	t.Run("unhandled error", func(t *testing.T) {
+		// given
+		backend.Reset()
+		ctx := goa.NewContext(context.Background(), rw, req, url.Values{})
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return goa.ErrInternal("failure")
+		}
+		// when
+		err := log.AccessLogMiddleware()(h)(ctx, rw, req)
+		// then
+		require.Error(t, err)
+		require.NotNil(t, backend.LastEntry())
+		assert.Equal(t, http.StatusInternalServerError, backend.LastEntry().Fields["status"])
+	})
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	// when
+	proxies, err := log.ParseTrustedProxies("10.0.0.0/8", "192.168.1.1", "::1")
+	// then
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.Equal(t, "10.0.0.0/8", proxies[0].String())
+	assert.Equal(t, "192.168.1.1/32", proxies[1].String())
+	assert.Equal(t, "::1/128", proxies[2].String())
+	_, err = log.ParseTrustedProxies("foo")
+	assert.Error(t, err)
+	_, err = log.ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level the level of a log entry
+type Level uint32
+
+const (
+	// PanicLevel the level of the entries logged with the Panic function
+	PanicLevel Level = iota
+	// ErrorLevel the level of the entries logged with the Error function
+	ErrorLevel
+	// WarnLevel the level of the entries logged with the Warn function
+	WarnLevel
+	// InfoLevel the level of the entries logged with the Info function
+	InfoLevel
+	// DebugLevel the level of the entries logged with the Debug function
+	DebugLevel
+)
+
+// String returns the name of the level
+func (l Level) String() string {
+	switch l {
+	case PanicLevel:
+		return "panic"
+	case ErrorLevel:
+		return "error"
+	case WarnLevel:
+		return "warning"
+	case InfoLevel:
+		return "info"
+	case DebugLevel:
+		return "debug"
+	default:
+		return fmt.Sprintf("Level(%d)", uint32(l))
+	}
+}
+
+// ParseLevel converts the given name into a Level
+func ParseLevel(lvl string) (Level, error) {
+	switch strings.ToLower(lvl) {
+	case "panic":
+		return PanicLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	}
+	return 0, fmt.Errorf("not a valid log level: %q", lvl)
+}
+
+// MarshalText returns the name of the level
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses the name of the level
+func (l *Level) UnmarshalText(text []byte) error {
+	lvl, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lvl
+	return nil
+}
+
+// Entry a log entry, as passed to the Backend
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Backend the interface to implement to write the log entries produced by the Error, Warn, Info,
+// Debug and Panic functions with a given logging library.
+type Backend interface {
+	// Enabled returns true if the entries at the given level should be logged.
+	// This method is called before the entry is built, so the cost of collecting the
+	// caller and request details is not paid for the entries that are discarded.
+	Enabled(level Level) bool
+	// Log writes the given entry. Backends may panic when the entry is at PanicLevel,
+	// otherwise the Panic function panics after the entry was written.
+	Log(entry Entry)
+}
+
+var (
+	backendMu sync.RWMutex
+	backend   Backend = NewLogrusBackend(logger)
+)
+
+// SetBackend sets the backend used to write all the log entries
+// (by default, a logrus backend configured with InitializeLogger).
+// The log level changes made at runtime with SetLevel and SetPackageLevel are discarded.
+func SetBackend(b Backend) {
+	backendMu.Lock()
+	backend = b
+	backendMu.Unlock()
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.clear()
+}
+
+// CurrentBackend returns the backend used to write all the log entries
+func CurrentBackend() Backend {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return backend
+}
@@ -0,0 +1,179 @@
+package log_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/log/logtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"panic", "error", "warning", "info", "debug"} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			lvl, err := log.ParseLevel(name)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, name, lvl.String())
+		})
+	}
+	t.Run("warn", func(t *testing.T) {
+		lvl, err := log.ParseLevel("WARN")
+		require.NoError(t, err)
+		assert.Equal(t, log.WarnLevel, lvl)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := log.ParseLevel("verbose")
+		require.Error(t, err)
+	})
+}
+
+func TestBackend(t *testing.T) {
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	ctx := log.WithFields(context.Background(), map[string]interface{}{"tenant": "foo"})
+
+	t.Run("entries are captured", func(t *testing.T) {
+		// given
+		backend.Reset()
+		// when
+		log.Error(ctx, map[string]interface{}{"err": "failure"}, "something went wrong with %s", "bar")
+		log.Warn(ctx, nil, "warning")
+		log.Info(nil, nil, "info")
+		// then
+		entries := backend.Entries()
+		require.Len(t, entries, 3)
+		assert.Equal(t, log.ErrorLevel, entries[0].Level)
+		assert.Equal(t, "something went wrong with bar", entries[0].Message)
+		assert.Equal(t, "failure", entries[0].Fields["err"])
+		assert.Equal(t, "foo", entries[0].Fields["tenant"])
+		assert.Equal(t, "log_test.TestBackend", entries[0].Fields["pkg"])
+		assert.Equal(t, log.WarnLevel, entries[1].Level)
+		assert.Equal(t, "foo", entries[1].Fields["tenant"])
+		assert.Equal(t, log.InfoLevel, entries[2].Level)
+		assert.Equal(t, "info", backend.LastEntry().Message)
+	})
+
+	t.Run("entries below the backend level are discarded", func(t *testing.T) {
+		// given
+		backend.Reset()
+		// when
+		log.Debug(ctx, nil, "debug")
+		// then
+		assert.Empty(t, backend.Entries())
+		assert.Nil(t, backend.LastEntry())
+		assert.False(t, log.IsDebug())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		// given
+		backend.Reset()
+		// when
+		assert.PanicsWithValue(t, "fatal failure", func() {
+			log.Panic(ctx, nil, "fatal %s", "failure")
+		})
+		// then
+		require.NotNil(t, backend.LastEntry())
+		assert.Equal(t, log.PanicLevel, backend.LastEntry().Level)
+		assert.Equal(t, "fatal failure", backend.LastEntry().Message)
+	})
+}
+
+type sugaredLogger struct {
+	entries []string
+}
+
+func (l *sugaredLogger) log(level, msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprintf("%s %s %v", level, msg, keysAndValues))
+}
+
+func (l *sugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.log("debug", msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.log("info", msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.log("warn", msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.log("error", msg, keysAndValues...)
+}
+
+func (l *sugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	l.log("panic", msg, keysAndValues...)
+}
+
+func TestZapBackend(t *testing.T) {
+	// given
+	l := &sugaredLogger{}
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(log.NewZapBackend(l, log.WarnLevel))
+	// when
+	log.Warn(nil, map[string]interface{}{"foo": "bar"}, "warning")
+	log.Info(nil, nil, "info")
+	// then
+	require.Len(t, l.entries, 1)
+	assert.Contains(t, l.entries[0], "warn warning [")
+	assert.Contains(t, l.entries[0], "foo bar")
+}
+
+func TestHook(t *testing.T) {
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	defer log.ResetLevels()
+	var entries []log.Entry
+	remove := log.AddHook(func(e log.Entry) {
+		entries = append(entries, e)
+	})
+	defer remove()
+
+	t.Run("entries are passed to the hook", func(t *testing.T) {
+		// given
+		entries = nil
+		// when
+		log.Info(nil, map[string]interface{}{"access_token": "foo"}, "info")
+		log.Debug(nil, nil, "debug")
+		// then the entries discarded by the backend are not passed to the hook
+		require.Len(t, entries, 1)
+		assert.Equal(t, "info", entries[0].Message)
+		assert.Equal(t, "*****", entries[0].Fields["access_token"])
+	})
+
+	t.Run("level change is kept", func(t *testing.T) {
+		// given
+		entries = nil
+		require.NoError(t, log.SetLevel(log.DebugLevel, 0))
+		// when
+		another := log.AddHook(func(log.Entry) {})
+		log.Debug(nil, nil, "debug")
+		another()
+		// then adding and removing a hook does not reset the level
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+		require.Len(t, entries, 1)
+		assert.Equal(t, "debug", entries[0].Message)
+		assert.Len(t, backend.Entries(), 2)
+	})
+
+	t.Run("removed hook", func(t *testing.T) {
+		// given
+		entries = nil
+		remove()
+		// when
+		log.Info(nil, nil, "info")
+		// then
+		assert.Empty(t, entries)
+	})
+}
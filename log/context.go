@@ -3,13 +3,54 @@ package log
 import (
 	"context"
 
+	"github.com/fabric8-services/fabric8-common/requestid"
+
 	jwt "github.com/dgrijalva/jwt-go"
-	"github.com/goadesign/goa/client"
-	"github.com/goadesign/goa/middleware"
 	goajwt "github.com/goadesign/goa/middleware/security/jwt"
 	"github.com/pkg/errors"
 )
 
+type contextKey int
+
+const (
+	// fieldsKey is the context key used to store the fields which are attached to all log entries
+	fieldsKey contextKey = iota + 1
+)
+
+// WithFields returns a copy of the given context in which the given fields are stored,
+// along with the fields that were already stored in the parent context (if any).
+// These fields are attached to all the log entries using the returned context (or a child of it),
+// which is useful to add some information such as the tenant, space ID, controller or job ID
+// once, at the beginning of a request or a background job.
+// The fields passed to the logging functions (Error, Warn, etc.) take precedence over the fields
+// stored in the context.
+func WithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parentFields := ContextFields(ctx)
+	allFields := make(map[string]interface{}, len(parentFields)+len(fields))
+	for k, v := range parentFields {
+		allFields[k] = v
+	}
+	for k, v := range fields {
+		allFields[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey, allFields)
+}
+
+// ContextFields returns the fields stored in the given context with WithFields,
+// or nil if there is none. The returned map must not be modified.
+func ContextFields(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	if fields, ok := ctx.Value(fieldsKey).(map[string]interface{}); ok {
+		return fields
+	}
+	return nil
+}
+
 // extractIdentityID obtains the identity ID out of the authentication context
 func extractIdentityID(ctx context.Context) (string, error) {
 	token := goajwt.ContextJWT(ctx)
@@ -24,15 +65,8 @@ func extractIdentityID(ctx context.Context) (string, error) {
 	return id.(string), nil
 }
 
-// ExtractRequestID obtains the request ID either from a goa client or middleware
+// ExtractRequestID obtains the request ID either from the requestid middleware, a goa client or middleware
+// (see requestid.FromContext)
 func ExtractRequestID(ctx context.Context) string {
-	if ctx == nil {
-		return ""
-	}
-	reqID := middleware.ContextRequestID(ctx)
-	if reqID == "" {
-		return client.ContextRequestID(ctx)
-	}
-
-	return reqID
+	return requestid.FromContext(ctx)
 }
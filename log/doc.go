@@ -1,3 +1,6 @@
 // Package log provides an implementation of our own logging API calls atop of
-// logrus logging package
+// a pluggable Backend. By default, the entries are written with the logrus logging
+// package, but they can also be written with zap or the standard `log/slog` package
+// (see SetBackend), or to several sinks at different levels, such as the console
+// and a RotatingFile (see NewMultiBackend and InitializeLoggerWithConfig).
 package log
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"sync"
+)
+
+// Hook a function called with each log entry written by the backend, once it was redacted.
+// Unlike a backend wrapping the current one (see SetBackend), a hook does not discard the
+// log level changes made at runtime, and is not removed when the backend is replaced.
+type Hook func(entry Entry)
+
+// hookRegistration a hook along with its identity, so that it can be removed
+type hookRegistration struct {
+	hook Hook
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []*hookRegistration
+)
+
+// AddHook registers the given hook, which is called with each entry written by the backend,
+// before it is written. Returns a function which removes the hook.
+func AddHook(h Hook) func() {
+	r := &hookRegistration{hook: h}
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, r)
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		for i, registered := range hooks {
+			if registered == r {
+				// copy, so that the hooks being fired are not modified
+				hooks = append(append([]*hookRegistration{}, hooks[:i]...), hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// fireHooks calls the registered hooks with the given entry
+func fireHooks(entry Entry) {
+	hooksMu.RLock()
+	registered := hooks
+	hooksMu.RUnlock()
+	for _, r := range registered {
+		r.hook(entry)
+	}
+}
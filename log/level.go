@@ -0,0 +1,193 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LevelSetter the interface implemented by the backends whose level can be changed at runtime
+type LevelSetter interface {
+	SetLevel(level Level)
+}
+
+// levelOverrides the log levels changed at runtime, globally or for some packages.
+// Until a change is made, the level of the backend applies. Once a change is made,
+// the backend is set at the most verbose level of all, and the entries are
+// filtered here, according to the package of the caller.
+type levelOverrides struct {
+	mu       sync.RWMutex
+	active   bool
+	initial  Level
+	global   Level
+	packages map[string]Level
+	timer    *time.Timer
+	// generation is incremented on each change, so that a timer which expired while
+	// a new change was being made does not revert this change
+	generation int
+}
+
+var levels = &levelOverrides{
+	packages: map[string]Level{},
+}
+
+// GetLevel returns the current global log level
+func GetLevel() Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	if levels.active {
+		return levels.global
+	}
+	return backendLevel(CurrentBackend())
+}
+
+// PackageLevels returns the log levels which were set for some packages with SetPackageLevel
+func PackageLevels() map[string]Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	result := make(map[string]Level, len(levels.packages))
+	for pkg, lvl := range levels.packages {
+		result[pkg] = lvl
+	}
+	return result
+}
+
+// SetLevel changes the global log level. The levels set for some packages with SetPackageLevel still apply.
+// If the given timeout is greater than 0, then all the log levels are reverted to their initial value
+// once the timeout expired (see ResetLevels).
+// Returns an error if the current backend does not support level changes.
+func SetLevel(level Level, timeout time.Duration) error {
+	return levels.set("", level, timeout)
+}
+
+// SetPackageLevel changes the log level for the given package, i.e., for all the entries whose
+// `pkg` field is the given package name or a sub-package/function of it (e.g. "jsonapi" matches
+// "jsonapi" and "jsonapi.ErrorHandler").
+// If the given timeout is greater than 0, then all the log levels are reverted to their initial value
+// once the timeout expired (see ResetLevels).
+// Returns an error if the current backend does not support level changes.
+func SetPackageLevel(pkg string, level Level, timeout time.Duration) error {
+	if pkg == "" {
+		return fmt.Errorf("missing package name")
+	}
+	return levels.set(pkg, level, timeout)
+}
+
+// ResetLevels reverts all the log level changes made with SetLevel and SetPackageLevel
+func ResetLevels() {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.reset()
+}
+
+func (l *levelOverrides) set(pkg string, level Level, timeout time.Duration) error {
+	b := CurrentBackend()
+	s, ok := b.(LevelSetter)
+	if !ok {
+		return fmt.Errorf("the log backend does not support level changes")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.active {
+		l.active = true
+		l.initial = backendLevel(b)
+		l.global = l.initial
+	}
+	if pkg == "" {
+		l.global = level
+	} else {
+		l.packages[pkg] = level
+	}
+	// the backend must not discard the entries of the most verbose package
+	max := l.global
+	for _, lvl := range l.packages {
+		if lvl > max {
+			max = lvl
+		}
+	}
+	s.SetLevel(max)
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.generation++
+	if timeout > 0 {
+		generation := l.generation
+		l.timer = time.AfterFunc(timeout, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.generation == generation {
+				l.reset()
+			}
+		})
+	}
+	return nil
+}
+
+// reset reverts the level of the backend to its initial value and removes all the overrides.
+// Must be called while holding the lock.
+func (l *levelOverrides) reset() {
+	if !l.active {
+		return
+	}
+	if s, ok := CurrentBackend().(LevelSetter); ok {
+		s.SetLevel(l.initial)
+	}
+	l.clear()
+}
+
+// clear removes all the overrides, without changing the level of the backend.
+// Must be called while holding the lock.
+func (l *levelOverrides) clear() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.generation++
+	l.active = false
+	l.packages = map[string]Level{}
+}
+
+// enabledForPackage returns true if an entry at the given level and logged from the given
+// package should be written, given the levels changed at runtime.
+func (l *levelOverrides) enabledForPackage(level Level, pkg string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.active {
+		return true
+	}
+	if lvl, found := l.packageLevel(pkg); found {
+		return lvl >= level
+	}
+	return l.global >= level
+}
+
+// packageLevel returns the level of the longest package name matching the given package.
+// Must be called while holding the lock.
+func (l *levelOverrides) packageLevel(pkg string) (Level, bool) {
+	names := make([]string, 0, len(l.packages))
+	for name := range l.packages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	for _, name := range names {
+		if pkg == name || strings.HasPrefix(pkg, name+".") || strings.HasPrefix(pkg, name+"/") {
+			return l.packages[name], true
+		}
+	}
+	return 0, false
+}
+
+// backendLevel returns the most verbose level enabled in the given backend
+func backendLevel(b Backend) Level {
+	for lvl := DebugLevel; lvl > PanicLevel; lvl-- {
+		if b.Enabled(lvl) {
+			return lvl
+		}
+	}
+	return PanicLevel
+}
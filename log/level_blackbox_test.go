@@ -0,0 +1,144 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/log/logtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLevel(t *testing.T) {
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+
+	t.Run("global level", func(t *testing.T) {
+		// given
+		defer log.ResetLevels()
+		backend.Reset()
+		// when
+		err := log.SetLevel(log.DebugLevel, 0)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+		log.Debug(nil, nil, "debug")
+		assert.Len(t, backend.Entries(), 1)
+		assert.True(t, log.IsDebug())
+	})
+
+	t.Run("package level", func(t *testing.T) {
+		// given
+		defer log.ResetLevels()
+		backend.Reset()
+		// when
+		err := log.SetPackageLevel("log_test", log.DebugLevel, 0)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, log.InfoLevel, log.GetLevel())
+		assert.Equal(t, map[string]log.Level{"log_test": log.DebugLevel}, log.PackageLevels())
+		log.Debug(nil, nil, "debug")
+		assert.Len(t, backend.Entries(), 1)
+	})
+
+	t.Run("other package level", func(t *testing.T) {
+		// given
+		defer log.ResetLevels()
+		backend.Reset()
+		// when
+		err := log.SetPackageLevel("jsonapi", log.DebugLevel, 0)
+		require.NoError(t, err)
+		err = log.SetPackageLevel("log_test", log.ErrorLevel, 0)
+		require.NoError(t, err)
+		// then
+		log.Debug(nil, nil, "debug")
+		log.Warn(nil, nil, "warn")
+		log.Error(nil, nil, "error")
+		require.Len(t, backend.Entries(), 1)
+		assert.Equal(t, "error", backend.LastEntry().Message)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		// given
+		err := log.SetLevel(log.DebugLevel, 0)
+		require.NoError(t, err)
+		err = log.SetPackageLevel("jsonapi", log.DebugLevel, 0)
+		require.NoError(t, err)
+		// when
+		log.ResetLevels()
+		// then
+		assert.Equal(t, log.InfoLevel, log.GetLevel())
+		assert.Empty(t, log.PackageLevels())
+		assert.False(t, backend.Enabled(log.DebugLevel))
+	})
+
+	t.Run("revert after timeout", func(t *testing.T) {
+		// when
+		err := log.SetLevel(log.DebugLevel, 10*time.Millisecond)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, log.InfoLevel, log.GetLevel())
+	})
+}
+
+func TestLevelHandler(t *testing.T) {
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	defer log.ResetLevels()
+	h := log.LevelHandler(time.Minute)
+
+	t.Run("get", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+		// then
+		require.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `{"level":"info"}`, rw.Body.String())
+	})
+
+	t.Run("put", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		body, _ := json.Marshal(map[string]string{"level": "debug", "pkg": "jsonapi"})
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/log/level", bytes.NewReader(body)))
+		// then
+		require.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `{"level":"info","packages":{"jsonapi":"debug"}}`, rw.Body.String())
+	})
+
+	t.Run("put with invalid level", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/log/level", bytes.NewReader([]byte(`{"level":"verbose"}`))))
+		// then
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("put without level", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/log/level", bytes.NewReader([]byte(`{"pkg":"jsonapi"}`))))
+		// then
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("post", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/log/level", nil))
+		// then
+		assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	})
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// LevelsState the representation of the log levels returned by the LevelHandler
+type LevelsState struct {
+	Level    Level            `json:"level"`
+	Packages map[string]Level `json:"packages,omitempty"`
+}
+
+// LevelChange the representation of a log level change, as submitted to the LevelHandler
+type LevelChange struct {
+	Level *Level `json:"level"`
+	// Pkg the optional package to which the level applies (see SetPackageLevel)
+	Pkg string `json:"pkg,omitempty"`
+	// Timeout the optional duration after which the level is reverted (e.g. "5m")
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// LevelHandler returns an HTTP handler to read (GET) and change (PUT) the log levels at runtime,
+// which can be mounted on the metrics or diagnose address, for example:
+//
+//	http.Handle("/log/level", log.LevelHandler(config.GetLogLevelResetTimeout()))
+//
+// A PUT request body is a JSON object such as `{"level":"debug","pkg":"jsonapi","timeout":"5m"}`,
+// in which only the `level` member is required. The changes are reverted after the timeout given in
+// the request or, if none was given, after the given default timeout. Both GET and PUT responses contain
+// the current global and package log levels.
+func LevelHandler(defaultTimeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var change LevelChange
+			if err := json.NewDecoder(req.Body).Decode(&change); err != nil {
+				http.Error(rw, "invalid log level change: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if change.Level == nil {
+				http.Error(rw, "invalid log level change: missing level", http.StatusBadRequest)
+				return
+			}
+			timeout := defaultTimeout
+			if change.Timeout != "" {
+				t, err := time.ParseDuration(change.Timeout)
+				if err != nil {
+					http.Error(rw, "invalid log level change timeout: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				timeout = t
+			}
+			var err error
+			if change.Pkg != "" {
+				err = SetPackageLevel(change.Pkg, *change.Level, timeout)
+			} else {
+				err = SetLevel(*change.Level, timeout)
+			}
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusConflict)
+				return
+			}
+			Info(req.Context(), map[string]interface{}{
+				"level":      change.Level.String(),
+				"target_pkg": change.Pkg,
+				"timeout":    timeout.String(),
+			}, "log level changed")
+		default:
+			rw.Header().Set("Allow", "GET, PUT")
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(LevelsState{
+			Level:    GetLevel(),
+			Packages: PackageLevels(),
+		})
+	})
+}
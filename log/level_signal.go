@@ -0,0 +1,45 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// HandleLevelSignals changes the global log level when the process receives a signal:
+// SIGUSR1 switches to the debug level, and SIGUSR2 reverts all the log level changes (see ResetLevels).
+// If the given timeout is greater than 0, then the debug level is automatically reverted after this timeout.
+// Call the returned function to stop handling the signals.
+func HandleLevelSignals(timeout time.Duration) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for {
+			select {
+			case s := <-signals:
+				switch s {
+				case syscall.SIGUSR1:
+					if err := SetLevel(DebugLevel, timeout); err != nil {
+						Error(nil, map[string]interface{}{"err": err}, "unable to switch to the debug log level")
+						continue
+					}
+					Info(nil, map[string]interface{}{"timeout": timeout.String()}, "switched to the debug log level")
+				case syscall.SIGUSR2:
+					ResetLevels()
+					Info(nil, map[string]interface{}{"level": GetLevel().String()}, "reverted the log level")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package log
+
+import "time"
+
+// HandleLevelSignals does nothing on Windows, which has no SIGUSR1 and SIGUSR2 signals:
+// use the LevelHandler to change the log level at runtime instead.
+func HandleLevelSignals(timeout time.Duration) func() {
+	return func() {}
+}
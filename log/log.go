@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/fabric8-services/fabric8-common/configuration"
+	"github.com/fabric8-services/fabric8-common/tracing"
 
 	"github.com/goadesign/goa"
 	log "github.com/sirupsen/logrus"
@@ -25,74 +29,58 @@ var (
 	}
 )
 
-// InitializeLogger creates a default logger with the given ouput format and log level
+// InitializeLogger creates a default logger with the given ouput format and log level,
+// and uses it as the backend for all the log entries.
 func InitializeLogger(logJSON bool, lvl string) {
-	logger = log.New()
-
 	logLevel, err := log.ParseLevel(lvl)
+	l := newLogrusLogger(log.InfoLevel, logJSON, os.Stdout)
 	if err != nil {
-		log.Warnf("unable to parse log level configuration error: %q", err)
+		l.Warnf("unable to parse log level configuration error: %q", err)
 		logLevel = log.ErrorLevel // reset to ERROR
 	}
-	log.SetLevel(logLevel)
-	logger.Level = logLevel
-
-	if logJSON {
-		customFormatter := new(log.JSONFormatter)
-		customFormatter.TimestampFormat = "2006-01-02 15:04:05"
-
-		log.SetFormatter(customFormatter)
-		customFormatter.DisableTimestamp = false
-		logger.Formatter = customFormatter
-	} else {
-		customFormatter := new(log.TextFormatter)
-		customFormatter.FullTimestamp = true
-		customFormatter.TimestampFormat = "2006-01-02 15:04:05"
-		log.SetFormatter(customFormatter)
-		logger.Formatter = customFormatter
-	}
-
-	logger.Out = os.Stdout
+	l.Level = logLevel
+	logger = l
+	SetBackend(NewLogrusBackend(l))
 }
 
 // NewCustomizedLogger creates a custom logger specifying the desired log level
 // and the log format flag. Returns the logger object and the error.
 func NewCustomizedLogger(level string, logJSON bool) (*log.Logger, error) {
-	logger := log.New()
+	return NewCustomizedLoggerWithOutput(level, logJSON, os.Stdout)
+}
 
+// NewCustomizedLoggerWithOutput creates a custom logger specifying the desired log level,
+// the log format flag and the output (e.g. os.Stderr or a RotatingFile). Returns the logger object and the error.
+func NewCustomizedLoggerWithOutput(level string, logJSON bool, out io.Writer) (*log.Logger, error) {
 	lv, err := log.ParseLevel(level)
 	if err != nil {
 		return nil, err
 	}
-	logger.Level = lv
+	return newLogrusLogger(lv, logJSON, out), nil
+}
 
+func newLogrusLogger(level log.Level, logJSON bool, out io.Writer) *log.Logger {
+	l := log.New()
+	l.Level = level
 	if logJSON {
 		customFormatter := new(log.JSONFormatter)
 		customFormatter.TimestampFormat = "2006-01-02 15:04:05"
-
-		log.SetFormatter(customFormatter)
 		customFormatter.DisableTimestamp = false
-
-		log.SetLevel(log.InfoLevel)
-		logger.Level = lv
-		logger.Formatter = customFormatter
+		l.Formatter = customFormatter
 	} else {
 		customFormatter := new(log.TextFormatter)
 		customFormatter.FullTimestamp = true
 		customFormatter.TimestampFormat = "2006-01-02 15:04:05"
-		log.SetFormatter(customFormatter)
-
-		log.SetLevel(log.DebugLevel)
-		logger.Level = lv
-		logger.Formatter = customFormatter
+		l.Formatter = customFormatter
 	}
-
-	logger.Out = os.Stdout
-
-	return logger, nil
+	l.Out = out
+	return l
 }
 
-// Logger returns the current logger object.
+// Logger returns the logrus logger configured with InitializeLogger (or the first sink configured with
+// InitializeLoggerWithConfig).
+// Note that this logger is not used to write the log entries if another
+// backend was set with SetBackend.
 func Logger() *log.Logger {
 	return logger
 }
@@ -101,7 +89,11 @@ func Logger() *log.Logger {
 // Useful if you need to do extra work that takes time to build the log statement
 // that is not required as part of normal execution flow
 func IsDebug() bool {
-	return logger.Level >= log.DebugLevel
+	if !CurrentBackend().Enabled(DebugLevel) {
+		return false
+	}
+	_, _, pName, _, _ := extractCallerDetails()
+	return levels.enabledForPackage(DebugLevel, pName)
 }
 
 // Error logs an error message that might contain the following attributes: pid,
@@ -111,58 +103,52 @@ func IsDebug() bool {
 // format and args are used to print a detailed message with the reasons of the
 // error log.
 func Error(ctx context.Context, fields map[string]interface{}, format string, args ...interface{}) {
-	if logger.Level >= log.ErrorLevel {
-		entry := log.WithField("pid", os.Getpid())
-
-		file, line, pName, fName, err := extractCallerDetails()
-		if err == nil {
-			entry = entry.WithField("file", file).WithField("pkg", pName).WithField("line", line).WithField("func", fName)
-		}
-
-		if ctx != nil {
-			entry = entry.WithField("req_id", ExtractRequestID(ctx))
-			identityID, err := extractIdentityID(ctx)
-			if err == nil {
-				entry = entry.WithField("identity_id", identityID)
-			}
-
-			if req := goa.ContextRequest(ctx); req != nil {
-				// Let's log some request details
-				if len(req.Header) > 0 {
-					headers := make(map[string]interface{}, len(req.Header))
-					for k, v := range req.Header {
-						// Hide sensitive information
-						if k == "Authorization" || k == "Cookie" {
-							headers[string(k)] = "*****"
-						} else {
-							headers[string(k)] = v
-						}
-					}
-					entry = entry.WithField("req_headers", headers)
-				}
-				if len(req.Params) > 0 {
-					entry = entry.WithField("req_params", req.Params)
+	b := CurrentBackend()
+	if !b.Enabled(ErrorLevel) {
+		return
+	}
+	entry := map[string]interface{}{
+		"pid": os.Getpid(),
+	}
+	file, line, pName, fName, err := extractCallerDetails()
+	if !levels.enabledForPackage(ErrorLevel, pName) {
+		return
+	}
+	if err == nil {
+		entry["file"] = file
+		entry["pkg"] = pName
+		entry["line"] = line
+		entry["func"] = fName
+	}
+	if ctx != nil {
+		addContextFields(ctx, entry)
+		if req := goa.ContextRequest(ctx); req != nil {
+			// Let's log some request details
+			// sensitive information such as the `Authorization` header is masked by the redactor
+			if len(req.Header) > 0 {
+				headers := make(map[string]interface{}, len(req.Header))
+				for k, v := range req.Header {
+					headers[k] = v
 				}
-				if req.ContentLength > 0 {
-					if mp, ok := req.Payload.(map[string]interface{}); ok && mp != nil {
-						entry = entry.WithField("req_payload", mp)
-					} else {
-						js, err := json.Marshal(req.Payload)
-						if err != nil {
-							js = []byte("<invalid JSON>")
-						}
-						entry = entry.WithField("req_payload", string(js))
+				entry["req_headers"] = headers
+			}
+			if len(req.Params) > 0 {
+				entry["req_params"] = req.Params
+			}
+			if req.ContentLength > 0 {
+				if mp, ok := req.Payload.(map[string]interface{}); ok && mp != nil {
+					entry["req_payload"] = mp
+				} else {
+					js, err := json.Marshal(req.Payload)
+					if err != nil {
+						js = []byte("<invalid JSON>")
 					}
+					entry["req_payload"] = string(js)
 				}
 			}
 		}
-
-		if len(args) > 0 {
-			entry.WithFields(fields).Errorf(format, args...)
-		} else {
-			entry.WithFields(fields).Errorln(format)
-		}
 	}
+	write(b, ErrorLevel, entry, fields, format, args...)
 }
 
 // Warn logs a warning message that might contain the following attributes:
@@ -172,28 +158,24 @@ func Error(ctx context.Context, fields map[string]interface{}, format string, ar
 // message. Likewise format and args are used to print a detailed message with
 // the reasons of the warning log.
 func Warn(ctx context.Context, fields map[string]interface{}, format string, args ...interface{}) {
-	if logger.Level >= log.WarnLevel {
-		entry := log.NewEntry(logger)
-
-		file, _, pName, fName, err := extractCallerDetails()
-		if err == nil {
-			entry = entry.WithField("file", file).WithField("pkg", pName).WithField("func", fName)
-		}
-
-		if ctx != nil {
-			entry = entry.WithField("req_id", ExtractRequestID(ctx))
-			identityID, err := extractIdentityID(ctx)
-			if err == nil { // Otherwise we don't use the identityID
-				entry = entry.WithField("identity_id", identityID)
-			}
-		}
-
-		if len(args) > 0 {
-			entry.WithFields(fields).Warnf(format, args...)
-		} else {
-			entry.WithFields(fields).Warnln(format)
-		}
+	b := CurrentBackend()
+	if !b.Enabled(WarnLevel) {
+		return
 	}
+	entry := map[string]interface{}{}
+	file, _, pName, fName, err := extractCallerDetails()
+	if !levels.enabledForPackage(WarnLevel, pName) {
+		return
+	}
+	if err == nil {
+		entry["file"] = file
+		entry["pkg"] = pName
+		entry["func"] = fName
+	}
+	if ctx != nil {
+		addContextFields(ctx, entry)
+	}
+	write(b, WarnLevel, entry, fields, format, args...)
 }
 
 // Info logs an info message that might contain the request id if provided by
@@ -201,53 +183,41 @@ func Warn(ctx context.Context, fields map[string]interface{}, format string, arg
 // attributes to the message. The format and args input arguments are used to
 // print a detailed information about the reasons of this log.
 func Info(ctx context.Context, fields map[string]interface{}, format string, args ...interface{}) {
-	if logger.Level >= log.InfoLevel {
-		entry := log.NewEntry(logger)
-
-		_, _, pName, _, err := extractCallerDetails()
-		if err == nil {
-			entry = entry.WithField("pkg", pName)
-		}
-
-		if ctx != nil {
-			entry = entry.WithField("req_id", ExtractRequestID(ctx))
-			identityID, err := extractIdentityID(ctx)
-			if err == nil { // Otherwise we don't use the identityID
-				entry = entry.WithField("identity_id", identityID)
-			}
-		}
-
-		if len(args) > 0 {
-			entry.WithFields(fields).Infof(format, args...)
-		} else {
-			entry.WithFields(fields).Infoln(format)
-		}
+	b := CurrentBackend()
+	if !b.Enabled(InfoLevel) {
+		return
 	}
+	entry := map[string]interface{}{}
+	_, _, pName, _, err := extractCallerDetails()
+	if !levels.enabledForPackage(InfoLevel, pName) {
+		return
+	}
+	if err == nil {
+		entry["pkg"] = pName
+	}
+	if ctx != nil {
+		addContextFields(ctx, entry)
+	}
+	write(b, InfoLevel, entry, fields, format, args...)
 }
 
 // Panic logs a panic message that might contain the following attributes:
 // the request id if provided by the context and the pid. In this function, the
 // parameter fields enables to additional attributes to the message. The format
 // and args input arguments are used to print a detailed information about the
-// reasons of this log.
+// reasons of this log. This function always panics, even if the entry is not logged.
 func Panic(ctx context.Context, fields map[string]interface{}, format string, args ...interface{}) {
-	if logger.Level >= log.ErrorLevel {
-		entry := log.WithField("pid", os.Getpid())
-
-		if ctx != nil {
-			entry = entry.WithField("req_id", ExtractRequestID(ctx))
-			identityID, err := extractIdentityID(ctx)
-			if err == nil { // Otherwise we don't use the identityID
-				entry = entry.WithField("identity_id", identityID)
-			}
+	b := CurrentBackend()
+	if b.Enabled(PanicLevel) {
+		entry := map[string]interface{}{
+			"pid": os.Getpid(),
 		}
-
-		if len(args) > 0 {
-			entry.WithFields(fields).Panicf(format, args...)
-		} else {
-			entry.WithFields(fields).Panicln(format)
+		if ctx != nil {
+			addContextFields(ctx, entry)
 		}
+		write(b, PanicLevel, entry, fields, format, args...)
 	}
+	panic(message(format, args...))
 }
 
 // Debug logs a debug message that might specifies the request id if provided by
@@ -255,28 +225,96 @@ func Panic(ctx context.Context, fields map[string]interface{}, format string, ar
 // attributes to the message. The format and args input arguments are used to
 // print a detailed information about the reasons of this log.
 func Debug(ctx context.Context, fields map[string]interface{}, format string, args ...interface{}) {
-	if logger.Level >= log.DebugLevel {
-		entry := log.NewEntry(logger)
+	b := CurrentBackend()
+	if !b.Enabled(DebugLevel) {
+		return
+	}
+	entry := map[string]interface{}{}
+	_, _, pName, _, err := extractCallerDetails()
+	if !levels.enabledForPackage(DebugLevel, pName) {
+		return
+	}
+	if err == nil {
+		entry["pkg"] = pName
+	}
+	if ctx != nil {
+		addContextFields(ctx, entry)
+	}
+	write(b, DebugLevel, entry, fields, format, args...)
+}
 
-		_, _, pName, _, err := extractCallerDetails()
-		if err == nil {
-			entry = entry.WithField("pkg", pName)
-		}
+// addContextFields adds the fields stored in the context with WithFields, along with
+// the request ID and the identity ID (if available) to the given entry fields.
+func addContextFields(ctx context.Context, entry map[string]interface{}) {
+	for k, v := range ContextFields(ctx) {
+		entry[k] = v
+	}
+	entry["req_id"] = ExtractRequestID(ctx)
+	identityID, err := extractIdentityID(ctx)
+	if err == nil { // Otherwise we don't use the identityID
+		entry["identity_id"] = identityID
+	}
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		sc := span.SpanContext()
+		entry["trace_id"] = sc.TraceID.String()
+		entry["span_id"] = sc.SpanID.String()
+	}
+}
 
-		if ctx != nil {
-			entry = entry.WithField("req_id", ExtractRequestID(ctx))
-			identityID, err := extractIdentityID(ctx)
-			if err == nil {
-				entry = entry.WithField("identity_id", identityID)
-			}
+// write merges the given fields into the entry fields, masks the sensitive data and
+// passes the resulting entry to the backend, unless it is suppressed by the sampler
+func write(b Backend, level Level, entry map[string]interface{}, fields map[string]interface{}, format string, args ...interface{}) {
+	if s := CurrentSampler(); s != nil && level != PanicLevel {
+		sampled, suppressed := s.sample(level, format, entry["pkg"])
+		if suppressed > 0 {
+			emitSummary(b, level, s.interval, format, entry["pkg"], suppressed)
 		}
-
-		if len(args) > 0 {
-			entry.WithFields(fields).Debugf(format, args...)
-		} else {
-			entry.WithFields(fields).Debugln(format)
+		if !sampled {
+			return
 		}
 	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	emit(b, level, entry, message(format, args...))
+}
+
+// emitSummary emits the summary of the entries with the given message format which were suppressed by the sampler
+func emitSummary(b Backend, level Level, interval time.Duration, format string, pkg interface{}, suppressed int) {
+	summary := map[string]interface{}{
+		"suppressed":        suppressed,
+		"suppressed_msg":    format,
+		"sampling_interval": interval.String(),
+	}
+	if pkg != nil {
+		summary["pkg"] = pkg
+	}
+	emit(b, level, summary, fmt.Sprintf("%d similar log entries were suppressed", suppressed))
+}
+
+// emit masks the sensitive data in the given fields and message, and passes the resulting entry to the hooks
+// and to the backend
+func emit(b Backend, level Level, fields map[string]interface{}, msg string) {
+	if r := CurrentRedactor(); r != nil {
+		fields = r.RedactFields(fields)
+		msg = r.RedactString(msg)
+	}
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  fields,
+	}
+	fireHooks(entry)
+	b.Log(entry)
+}
+
+// message formats the message of a log entry
+func message(format string, args ...interface{}) string {
+	if len(args) > 0 {
+		return fmt.Sprintf(format, args...)
+	}
+	return format
 }
 
 // extractCallerDetails gets information about the file, line and function that
@@ -1,36 +1,31 @@
 package log
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
-	"io"
 	"net"
 	"net/http"
 	"time"
 
+	"github.com/fabric8-services/fabric8-common/requestid"
+
 	"github.com/goadesign/goa"
 
 	"context"
 )
 
-type middlewareKey int
-
-const (
-	// ReqIDKey is the context key used by the goa RequestID middleware to store the request ID value.
-	reqIDKey middlewareKey = iota + 1
-)
-
 // LogRequest creates a request logger for the goa middleware.
 // This goa middleware is aware of the RequestID middleware and identity id
 // if registered after it leverages the request and identity ID for logging.
+// If no request ID was set by a previous middleware, then a new one is generated and stored
+// in the context, so that all the subsequent log entries and outgoing requests use it.
 // If verbose is true then the middlware logs the request and response bodies.
 func LogRequest(verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-			reqID := ctx.Value(reqIDKey)
-			if reqID == nil {
-				reqID = shortID()
+			reqID := requestid.FromContext(ctx)
+			if reqID == "" {
+				reqID = requestid.New()
+				ctx = requestid.NewContext(ctx, reqID)
 			}
 			ctx = goa.WithLogContext(ctx, "req_id", reqID)
 
@@ -108,14 +103,6 @@ func LogRequest(verbose bool) goa.Middleware {
 	}
 }
 
-// shortID produces a "unique" 6 bytes long string.
-// Do not use as a reliable way to get unique IDs, instead use for things like logging.
-func shortID() string {
-	b := make([]byte, 6)
-	io.ReadFull(rand.Reader, b)
-	return base64.StdEncoding.EncodeToString(b)
-}
-
 // from makes a best effort to compute the request client IP.
 func from(req *http.Request) string {
 	if f := req.Header.Get("X-Forwarded-For"); f != "" {
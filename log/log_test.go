@@ -2,9 +2,12 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"testing"
 
+	"github.com/fabric8-services/fabric8-common/tracing"
+
 	logrus "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -83,3 +86,46 @@ func TestInfoMsgFieldHasPrefix(t *testing.T) {
 		assert.Equal(t, fields["level"], "info")
 	})
 }
+
+func TestInfoWithContextFields(t *testing.T) {
+	ctx := WithFields(context.Background(), map[string]interface{}{"space_id": "foo", "tenant": "bar"})
+	ctx = WithFields(ctx, map[string]interface{}{"job": "cleanup", "tenant": "baz"})
+	LogAndAssertJSON(t, func() {
+		Info(ctx, map[string]interface{}{"job": "override"}, "test")
+	}, func(fields logrus.Fields) {
+		assert.Equal(t, "test", fields["msg"])
+		assert.Equal(t, "foo", fields["space_id"])
+		assert.Equal(t, "baz", fields["tenant"])
+		// fields passed to the logging function take precedence
+		assert.Equal(t, "override", fields["job"])
+	})
+}
+
+func TestInfoWithTraceContext(t *testing.T) {
+	ctx, span := tracing.StartSpan(context.Background(), "test", tracing.SpanKindInternal)
+	LogAndAssertJSON(t, func() {
+		Info(ctx, nil, "test")
+	}, func(fields logrus.Fields) {
+		assert.Equal(t, span.SpanContext().TraceID.String(), fields["trace_id"])
+		assert.Equal(t, span.SpanContext().SpanID.String(), fields["span_id"])
+	})
+}
+
+func TestWithFieldsDoesNotModifyParentContext(t *testing.T) {
+	parent := WithFields(context.Background(), map[string]interface{}{"foo": "bar"})
+	child := WithFields(parent, map[string]interface{}{"foo": "baz", "name": "value"})
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, ContextFields(parent))
+	assert.Equal(t, map[string]interface{}{"foo": "baz", "name": "value"}, ContextFields(child))
+	assert.Nil(t, ContextFields(context.Background()))
+}
+
+func TestErrorWithContextFields(t *testing.T) {
+	ctx := WithFields(context.Background(), map[string]interface{}{"space_id": "foo"})
+	LogAndAssertJSON(t, func() {
+		Error(ctx, nil, "test")
+	}, func(fields logrus.Fields) {
+		assert.Equal(t, "error", fields["level"])
+		assert.Equal(t, "foo", fields["space_id"])
+		assert.Equal(t, "log.TestErrorWithContextFields", fields["pkg"])
+	})
+}
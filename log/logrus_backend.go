@@ -0,0 +1,41 @@
+package log
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// NewLogrusBackend returns a Backend which writes the log entries with the given logrus logger
+func NewLogrusBackend(logger *log.Logger) Backend {
+	return &logrusBackend{logger: logger}
+}
+
+type logrusBackend struct {
+	logger *log.Logger
+}
+
+func (b *logrusBackend) Enabled(level Level) bool {
+	return b.logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func (b *logrusBackend) SetLevel(level Level) {
+	b.logger.SetLevel(logrusLevel(level))
+}
+
+func (b *logrusBackend) Log(e Entry) {
+	log.NewEntry(b.logger).WithFields(e.Fields).WithTime(e.Time).Log(logrusLevel(e.Level), e.Message)
+}
+
+func logrusLevel(level Level) log.Level {
+	switch level {
+	case PanicLevel:
+		return log.PanicLevel
+	case ErrorLevel:
+		return log.ErrorLevel
+	case WarnLevel:
+		return log.WarnLevel
+	case InfoLevel:
+		return log.InfoLevel
+	default:
+		return log.DebugLevel
+	}
+}
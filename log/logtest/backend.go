@@ -0,0 +1,79 @@
+// Package logtest provides a log backend which captures the log entries, to verify
+// in tests that the expected entries were logged.
+package logtest
+
+import (
+	"sync"
+
+	"github.com/fabric8-services/fabric8-common/log"
+)
+
+// Backend a log backend which keeps all the entries in memory
+type Backend struct {
+	mu      sync.RWMutex
+	level   log.Level
+	entries []log.Entry
+}
+
+// NewBackend returns a new backend which captures the entries at the given level or above.
+// Use it with `log.SetBackend`, for example:
+//
+//	backend := logtest.NewBackend(log.DebugLevel)
+//	defer log.SetBackend(log.CurrentBackend())
+//	log.SetBackend(backend)
+//
+// Since the backend is set for the whole log package, the tests using it should not run in parallel.
+func NewBackend(level log.Level) *Backend {
+	return &Backend{
+		level:   level,
+		entries: []log.Entry{},
+	}
+}
+
+// Enabled returns true if the given level is the level of the backend or above
+func (b *Backend) Enabled(level log.Level) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.level >= level
+}
+
+// SetLevel changes the level of the backend
+func (b *Backend) SetLevel(level log.Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.level = level
+}
+
+// Log captures the given entry
+func (b *Backend) Log(e log.Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, e)
+}
+
+// Entries returns a copy of all the captured entries
+func (b *Backend) Entries() []log.Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	entries := make([]log.Entry, len(b.entries))
+	copy(entries, b.entries)
+	return entries
+}
+
+// LastEntry returns the last captured entry, or nil if there is none
+func (b *Backend) LastEntry() *log.Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.entries) == 0 {
+		return nil
+	}
+	e := b.entries[len(b.entries)-1]
+	return &e
+}
+
+// Reset removes all the captured entries
+func (b *Backend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = []log.Entry{}
+}
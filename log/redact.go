@@ -0,0 +1,272 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultMask the value which replaces the sensitive data
+const DefaultMask = "*****"
+
+var (
+	// DefaultRedactedKeys the (case-insensitive) names of the fields, headers, params and
+	// JSON payload members whose values are masked by default
+	DefaultRedactedKeys = []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"password",
+		"client_secret",
+		"access_token",
+		"refresh_token",
+		"id_token",
+	}
+
+	// DefaultRedactedPaths the paths of the values which are masked by default: the OAuth
+	// authorization code passed in the query params of the requests
+	DefaultRedactedPaths = []string{
+		"req_params.code",
+	}
+
+	// DefaultRedactedPatterns the patterns of the sensitive data which are masked by default
+	// in all the string values and messages: bearer tokens, JWTs, secrets in URL-encoded
+	// or JSON strings, and email addresses
+	DefaultRedactedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`),
+		regexp.MustCompile(`\beyJ[a-zA-Z0-9\-_]+\.[a-zA-Z0-9\-_]+\.[a-zA-Z0-9\-_]*`),
+		regexp.MustCompile(`(?i)((?:client_secret|password|access_token|refresh_token)=)[^&\s"]+`),
+		regexp.MustCompile(`(?i)("(?:client_secret|password|access_token|refresh_token)"\s*:\s*")[^"]*`),
+		regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	}
+)
+
+// Redactor masks the sensitive data in the log entries: the values of the fields, headers, params
+// and JSON payload members with a given name or at a given path, and the parts of all the string values
+// and messages which match a given pattern.
+type Redactor struct {
+	keys     map[string]struct{}
+	paths    [][]string
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+// RedactorOption an option to configure the Redactor
+type RedactorOption func(*Redactor)
+
+// WithRedactedKeys masks the values of the fields, headers, params and JSON members with the given
+// (case-insensitive) names, at any depth, in addition to the DefaultRedactedKeys
+func WithRedactedKeys(keys ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, k := range keys {
+			r.keys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// WithRedactedPaths masks the values at the given dot-separated paths (e.g. "data.attributes.email").
+// A path matches the end of the path of a value in the entry fields, so "data.attributes.email"
+// also applies to the payload logged in the "req_payload" field. A "*" segment matches any name.
+func WithRedactedPaths(paths ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, p := range paths {
+			r.paths = append(r.paths, strings.Split(strings.ToLower(p), "."))
+		}
+	}
+}
+
+// WithRedactedPatterns masks the parts of all the string values and messages which match the given patterns,
+// in addition to the DefaultRedactedPatterns. If a pattern has a capturing group, then the first group is kept
+// (e.g. the `client_secret=` prefix) and only the remaining part of the match is masked.
+func WithRedactedPatterns(patterns ...*regexp.Regexp) RedactorOption {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, patterns...)
+	}
+}
+
+// WithMask replaces the sensitive data with the given mask instead of the DefaultMask
+func WithMask(mask string) RedactorOption {
+	return func(r *Redactor) {
+		r.mask = mask
+	}
+}
+
+// NewRedactor returns a new Redactor configured with the defaults and the given options
+func NewRedactor(options ...RedactorOption) *Redactor {
+	r := &Redactor{
+		keys:     map[string]struct{}{},
+		patterns: append([]*regexp.Regexp{}, DefaultRedactedPatterns...),
+		mask:     DefaultMask,
+	}
+	WithRedactedKeys(DefaultRedactedKeys...)(r)
+	WithRedactedPaths(DefaultRedactedPaths...)(r)
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+var (
+	redactorMu sync.RWMutex
+	redactor   = NewRedactor()
+)
+
+// SetRedactor sets the redactor applied on all the log entries (by default, a Redactor
+// with no option). Use nil to disable the redaction.
+func SetRedactor(r *Redactor) {
+	redactorMu.Lock()
+	defer redactorMu.Unlock()
+	redactor = r
+}
+
+// CurrentRedactor returns the redactor applied on all the log entries, or nil if the redaction is disabled
+func CurrentRedactor() *Redactor {
+	redactorMu.RLock()
+	defer redactorMu.RUnlock()
+	return redactor
+}
+
+// RedactFields returns a copy of the given fields in which the sensitive data is masked
+func (r *Redactor) RedactFields(fields map[string]interface{}) map[string]interface{} {
+	if r == nil {
+		return fields
+	}
+	return r.redactMap(nil, fields)
+}
+
+// RedactHeaders returns a copy of the given headers in which the sensitive data is masked
+func (r *Redactor) RedactHeaders(headers http.Header) map[string]interface{} {
+	fields := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		fields[k] = v
+	}
+	return r.RedactFields(fields)
+}
+
+// RedactString masks the parts of the given string which match the patterns of the redactor.
+// If the string is a JSON object or array, then its members are also masked according to
+// the keys and paths of the redactor.
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	return r.redactString(nil, s)
+}
+
+func (r *Redactor) redactMap(path []string, m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		p := append(path[:len(path):len(path)], strings.ToLower(k))
+		if r.isRedacted(p) {
+			result[k] = r.mask
+			continue
+		}
+		result[k] = r.redactValue(p, v)
+	}
+	return result
+}
+
+func (r *Redactor) redactValue(path []string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(path, v)
+	case []string:
+		result := make([]string, len(v))
+		for i, s := range v {
+			result[i] = r.redactString(path, s)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = r.redactValue(path, e)
+		}
+		return result
+	case map[string]interface{}:
+		return r.redactMap(path, v)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return r.redactMap(path, m)
+	case map[string][]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return r.redactMap(path, m)
+	case url.Values:
+		return r.redactValue(path, map[string][]string(v))
+	case http.Header:
+		return r.redactValue(path, map[string][]string(v))
+	case error:
+		// only convert the error into a string if it contains some sensitive data
+		msg := v.Error()
+		if redacted := r.redactString(path, msg); redacted != msg {
+			return redacted
+		}
+		return v
+	default:
+		// the other maps with string keys, including the named types which are not matched above
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[k.String()] = rv.MapIndex(k).Interface()
+		}
+		return r.redactMap(path, m)
+	}
+}
+
+func (r *Redactor) redactString(path []string, s string) string {
+	if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(trimmed), &doc); err == nil {
+			if js, err := json.Marshal(r.redactValue(path, doc)); err == nil {
+				return string(js)
+			}
+		}
+	}
+	for _, p := range r.patterns {
+		if p.NumSubexp() > 0 {
+			s = p.ReplaceAllString(s, "${1}"+r.mask)
+		} else {
+			s = p.ReplaceAllLiteralString(s, r.mask)
+		}
+	}
+	return s
+}
+
+// isRedacted returns true if the value at the given path must be masked
+func (r *Redactor) isRedacted(path []string) bool {
+	if _, found := r.keys[path[len(path)-1]]; found {
+		return true
+	}
+	for _, p := range r.paths {
+		if matchesPathSuffix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPathSuffix(path, suffix []string) bool {
+	if len(suffix) > len(path) {
+		return false
+	}
+	offset := len(path) - len(suffix)
+	for i, s := range suffix {
+		if s != "*" && s != path[offset+i] {
+			return false
+		}
+	}
+	return true
+}
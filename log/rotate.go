@@ -0,0 +1,274 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat the format of the timestamp in the name of the rotated log files
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile a log file which is rotated once it reaches a given size or after a given interval.
+// The rotated files are renamed after the time of the rotation (e.g. "app-2019-02-01T10-00-00.000.log"
+// for "app.log") in the same directory, and removed once there are too many of them or they are too old.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	maxAge     time.Duration
+	mu         sync.Mutex
+	// file the current file, or nil if it could not be reopened after the last rotation
+	file     *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+	// lastBackupTime and lastBackupCounter identify the last rotated file
+	lastBackupTime    string
+	lastBackupCounter int
+}
+
+// RotatingFileOption an option to configure the RotatingFile
+type RotatingFileOption func(*RotatingFile)
+
+// WithMaxSize rotates the file before it exceeds the given size, in bytes (0 disables the size-based rotation)
+func WithMaxSize(size int64) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.maxSize = size
+	}
+}
+
+// WithRotationInterval rotates the file once it was opened for the given interval
+// (0 disables the time-based rotation)
+func WithRotationInterval(interval time.Duration) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.interval = interval
+	}
+}
+
+// WithMaxBackups removes the oldest rotated files once there are more than the given number of them
+// (0 retains all of them)
+func WithMaxBackups(n int) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.maxBackups = n
+	}
+}
+
+// WithMaxAge removes the rotated files which are older than the given duration (0 retains all of them)
+func WithMaxAge(age time.Duration) RotatingFileOption {
+	return func(f *RotatingFile) {
+		f.maxAge = age
+	}
+}
+
+// NewRotatingFile opens (or creates) the log file at the given path, and returns a RotatingFile which
+// appends the entries to it. By default, the file is never rotated.
+func NewRotatingFile(path string, options ...RotatingFileOption) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path: path,
+		now:  time.Now,
+	}
+	for _, opt := range options {
+		opt(f)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes the given bytes to the file, after rotating it if needed. If the file could not be
+// reopened after the last rotation, then it is opened again first.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return 0, err
+	}
+	if f.size > 0 && ((f.maxSize > 0 && f.size+int64(len(p)) > f.maxSize) ||
+		(f.interval > 0 && f.now().Sub(f.openedAt) >= f.interval)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately, e.g. when receiving a signal from an external log rotation tool
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// Close closes the file. The subsequent writes fail.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// ensureOpen returns an error if the file was closed, or opens it again if it could not be reopened
+// after the last rotation. Must be called while holding the lock.
+func (f *RotatingFile) ensureOpen() error {
+	if f.closed {
+		return fmt.Errorf("log file '%s' is closed", f.path)
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+// open opens the file in append mode. Must be called while holding the lock (or during the initialization).
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// rotate renames the current file, opens a new one and removes the old rotated files.
+// If the file cannot be renamed, then the current file is reopened. If the new file cannot
+// be opened, then it is opened again on the next write.
+// Must be called while holding the lock.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext)
+	// the files rotated within the same millisecond are numbered, even if the previous ones were removed
+	ts := f.now().Format(backupTimeFormat)
+	if ts != f.lastBackupTime {
+		f.lastBackupTime = ts
+		f.lastBackupCounter = 0
+	}
+	backup := f.backupName(prefix, ts, f.lastBackupCounter, ext)
+	for fileExists(backup) {
+		f.lastBackupCounter++
+		backup = f.backupName(prefix, ts, f.lastBackupCounter, ext)
+	}
+	f.lastBackupCounter++
+	if err := os.Rename(f.path, backup); err != nil {
+		// keep writing to the current file
+		f.open()
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.removeOldBackups()
+}
+
+func (f *RotatingFile) backupName(prefix, ts string, counter int, ext string) string {
+	if counter == 0 {
+		return fmt.Sprintf("%s-%s%s", prefix, ts, ext)
+	}
+	return fmt.Sprintf("%s-%s.%d%s", prefix, ts, counter, ext)
+}
+
+// removeOldBackups removes the rotated files beyond the maximum number of backups or the maximum age
+func (f *RotatingFile) removeOldBackups() error {
+	if f.maxBackups <= 0 && f.maxAge <= 0 {
+		return nil
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		return err
+	}
+	for i, backup := range backups {
+		remove := f.maxBackups > 0 && i >= f.maxBackups
+		if !remove && f.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && f.now().Sub(info.ModTime()) > f.maxAge {
+				remove = true
+			}
+		}
+		if remove {
+			if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Backups returns the paths of the rotated files, from the most recent to the oldest
+func (f *RotatingFile) Backups() ([]string, error) {
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext)
+	matches, err := filepath.Glob(prefix + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		path    string
+		time    string
+		counter int
+	}
+	backups := make([]backup, 0, len(matches))
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, prefix+"-"), ext)
+		if len(suffix) < len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)]); err != nil {
+			continue
+		}
+		b := backup{path: m, time: suffix[:len(backupTimeFormat)]}
+		if counter := suffix[len(backupTimeFormat):]; counter != "" {
+			if _, err := fmt.Sscanf(counter, ".%d", &b.counter); err != nil {
+				continue
+			}
+		}
+		backups = append(backups, b)
+	}
+	// the timestamps in the names sort in chronological order
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].time != backups[j].time {
+			return backups[i].time > backups[j].time
+		}
+		return backups[i].counter > backups[j].counter
+	})
+	result := make([]string, len(backups))
+	for i, b := range backups {
+		result[i] = b.path
+	}
+	return result, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,140 @@
+package log_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("rotate by size", func(t *testing.T) {
+		// given
+		dir, err := ioutil.TempDir("", "rotate")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "app.log")
+		f, err := log.NewRotatingFile(path, log.WithMaxSize(10))
+		require.NoError(t, err)
+		defer f.Close()
+		// when
+		_, err = f.Write([]byte("12345678\n"))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("abcdefgh\n"))
+		require.NoError(t, err)
+		// then
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "abcdefgh\n", string(content))
+		backups, err := f.Backups()
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		content, err = ioutil.ReadFile(backups[0])
+		require.NoError(t, err)
+		assert.Equal(t, "12345678\n", string(content))
+	})
+
+	t.Run("rotate by interval", func(t *testing.T) {
+		// given
+		dir, err := ioutil.TempDir("", "rotate")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "app.log")
+		f, err := log.NewRotatingFile(path, log.WithRotationInterval(20*time.Millisecond))
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = f.Write([]byte("first\n"))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("second\n"))
+		require.NoError(t, err)
+		// when
+		time.Sleep(30 * time.Millisecond)
+		_, err = f.Write([]byte("third\n"))
+		require.NoError(t, err)
+		// then
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "third\n", string(content))
+		backups, err := f.Backups()
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		content, err = ioutil.ReadFile(backups[0])
+		require.NoError(t, err)
+		assert.Equal(t, "first\nsecond\n", string(content))
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		// given
+		dir, err := ioutil.TempDir("", "rotate")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "app.log")
+		f, err := log.NewRotatingFile(path, log.WithMaxBackups(2))
+		require.NoError(t, err)
+		defer f.Close()
+		// when
+		for _, s := range []string{"1", "2", "3", "4"} {
+			_, err = f.Write([]byte(s))
+			require.NoError(t, err)
+			require.NoError(t, f.Rotate())
+		}
+		// then
+		backups, err := f.Backups()
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+		for i, expected := range []string{"4", "3"} {
+			content, err := ioutil.ReadFile(backups[i])
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(content))
+		}
+	})
+
+	t.Run("reopen after a failed rotation", func(t *testing.T) {
+		// given
+		dir, err := ioutil.TempDir("", "rotate")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "logs", "app.log")
+		f, err := log.NewRotatingFile(path)
+		require.NoError(t, err)
+		defer f.Close()
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "logs")))
+		// when
+		err = f.Rotate()
+		// then
+		require.Error(t, err)
+		_, err = f.Write([]byte("lost"))
+		require.Error(t, err)
+		// the file is opened again once it is possible
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+		_, err = f.Write([]byte("foo"))
+		require.NoError(t, err)
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(content))
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		// given
+		dir, err := ioutil.TempDir("", "rotate")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		f, err := log.NewRotatingFile(filepath.Join(dir, "app.log"))
+		require.NoError(t, err)
+		// when
+		require.NoError(t, f.Close())
+		_, err = f.Write([]byte("foo"))
+		// then
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/configuration"
+)
+
+// Sampler limits the number of entries logged with the same message: during each interval,
+// the first entries are logged, then only one out of a given number of entries is logged.
+// The entries are grouped by level and message format (i.e., before the arguments are applied),
+// so the entries logged by a single call site are sampled together.
+// Once an interval expired, a summary entry with the number of entries which were suppressed
+// during this interval is logged, either before the next similar entry, or within the next interval.
+// The groups without any entry during an interval are discarded, and the remaining summaries are
+// logged when the sampler is closed.
+type Sampler struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+	mu         sync.Mutex
+	counters   map[string]*sampleCounter
+	now        func() time.Time
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+type sampleCounter struct {
+	level      Level
+	format     string
+	pkg        interface{}
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// NewSampler returns a new Sampler which logs the first entries with the same message
+// during each interval, and then one out of `thereafter` entries (or none if thereafter is 0).
+// Close the sampler once it is not used anymore.
+func NewSampler(interval time.Duration, first, thereafter int) *Sampler {
+	s := &Sampler{
+		interval:   interval,
+		first:      first,
+		thereafter: thereafter,
+		counters:   map[string]*sampleCounter{},
+		now:        time.Now,
+		done:       make(chan struct{}),
+	}
+	if interval > 0 {
+		go s.run()
+	}
+	return s
+}
+
+// sample returns true if the entry with the given level and message format should be logged, along with
+// the number of similar entries which were suppressed during the previous interval (if it just expired)
+func (s *Sampler) sample(level Level, format string, pkg interface{}) (bool, int) {
+	now := s.now()
+	key := level.String() + ":" + format
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, found := s.counters[key]
+	if !found {
+		c = &sampleCounter{level: level, format: format, start: now}
+		s.counters[key] = c
+	}
+	c.pkg = pkg
+	var suppressed int
+	if now.Sub(c.start) >= s.interval {
+		suppressed = c.suppressed
+		c.start = now
+		c.count = 0
+		c.suppressed = 0
+	}
+	c.count++
+	if c.count <= s.first || (s.thereafter > 0 && (c.count-s.first)%s.thereafter == 0) {
+		return true, suppressed
+	}
+	c.suppressed++
+	return false, suppressed
+}
+
+// run logs the summaries of the expired intervals and discards their counters, until the sampler is closed
+func (s *Sampler) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(false)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flush removes the counters of the expired intervals (or all of them if `all` is true),
+// and logs the summaries of their suppressed entries
+func (s *Sampler) flush(all bool) {
+	now := s.now()
+	var expired []*sampleCounter
+	s.mu.Lock()
+	for key, c := range s.counters {
+		if all || now.Sub(c.start) >= s.interval {
+			delete(s.counters, key)
+			if c.suppressed > 0 {
+				expired = append(expired, c)
+			}
+		}
+	}
+	s.mu.Unlock()
+	b := CurrentBackend()
+	for _, c := range expired {
+		if b.Enabled(c.level) {
+			emitSummary(b, c.level, s.interval, c.format, c.pkg, c.suppressed)
+		}
+	}
+}
+
+// Close stops the sampler, and logs the summaries of the entries which were suppressed
+// during the current intervals
+func (s *Sampler) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.flush(true)
+	})
+}
+
+var (
+	samplerMu sync.RWMutex
+	sampler   *Sampler
+)
+
+// SetSampler sets the sampler applied on all the log entries, except the ones logged with Panic.
+// Use nil (the default) to disable the sampling.
+func SetSampler(s *Sampler) {
+	samplerMu.Lock()
+	defer samplerMu.Unlock()
+	sampler = s
+}
+
+// CurrentSampler returns the sampler applied on all the log entries, or nil if the sampling is disabled
+func CurrentSampler() *Sampler {
+	samplerMu.RLock()
+	defer samplerMu.RUnlock()
+	return sampler
+}
+
+// InitializeSampling sets the sampler according to the given configuration (see `Registry.GetLogSamplingInterval`,
+// `Registry.GetLogSamplingFirst` and `Registry.GetLogSamplingThereafter`). The sampling is disabled if the
+// configured interval is 0.
+// The previous sampler, if any, is closed.
+func InitializeSampling(config *configuration.Registry) {
+	if previous := CurrentSampler(); previous != nil {
+		defer previous.Close()
+	}
+	if config.GetLogSamplingInterval() <= 0 {
+		SetSampler(nil)
+		return
+	}
+	SetSampler(NewSampler(config.GetLogSamplingInterval(), config.GetLogSamplingFirst(), config.GetLogSamplingThereafter()))
+}
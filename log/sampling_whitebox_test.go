@@ -0,0 +1,128 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type captureBackend struct {
+	entries []Entry
+}
+
+func (b *captureBackend) Enabled(level Level) bool {
+	return true
+}
+
+func (b *captureBackend) Log(e Entry) {
+	b.entries = append(b.entries, e)
+}
+
+func TestSampler(t *testing.T) {
+	// given
+	now := time.Now()
+	s := NewSampler(time.Minute, 2, 3)
+	defer s.Close()
+	s.now = func() time.Time {
+		return now
+	}
+	b := &captureBackend{}
+	defer SetBackend(CurrentBackend())
+	SetBackend(b)
+	defer SetSampler(CurrentSampler())
+	SetSampler(s)
+
+	t.Run("first entries then every 3rd", func(t *testing.T) {
+		// when
+		for i := 1; i <= 10; i++ {
+			Info(nil, nil, "message %d", i)
+		}
+		Warn(nil, nil, "other message")
+		// then
+		require.Len(t, b.entries, 5)
+		assert.Equal(t, "message 1", b.entries[0].Message)
+		assert.Equal(t, "message 2", b.entries[1].Message)
+		assert.Equal(t, "message 5", b.entries[2].Message)
+		assert.Equal(t, "message 8", b.entries[3].Message)
+		assert.Equal(t, "other message", b.entries[4].Message)
+	})
+
+	t.Run("summary in next interval", func(t *testing.T) {
+		// given
+		b.entries = nil
+		now = now.Add(time.Minute)
+		// when
+		Info(nil, nil, "message %d", 11)
+		// then
+		require.Len(t, b.entries, 2)
+		assert.Equal(t, "6 similar log entries were suppressed", b.entries[0].Message)
+		assert.Equal(t, InfoLevel, b.entries[0].Level)
+		assert.Equal(t, 6, b.entries[0].Fields["suppressed"])
+		assert.Equal(t, "message %d", b.entries[0].Fields["suppressed_msg"])
+		assert.Equal(t, "log.TestSampler", b.entries[0].Fields["pkg"])
+		assert.Equal(t, "message 11", b.entries[1].Message)
+	})
+
+	t.Run("summary of the expired intervals", func(t *testing.T) {
+		// given
+		b.entries = nil
+		for i := 0; i < 4; i++ {
+			Info(nil, nil, "burst %d", i)
+		}
+		now = now.Add(time.Minute)
+		Info(nil, nil, "recent message")
+		b.entries = nil
+		// when
+		s.flush(false)
+		// then the summary of the burst is logged, and the expired counters are discarded
+		require.Len(t, b.entries, 1)
+		assert.Equal(t, "2 similar log entries were suppressed", b.entries[0].Message)
+		assert.Equal(t, "burst %d", b.entries[0].Fields["suppressed_msg"])
+		assert.Len(t, s.counters, 1)
+	})
+
+	t.Run("summary on close", func(t *testing.T) {
+		// given
+		b.entries = nil
+		for i := 0; i < 3; i++ {
+			Warn(nil, nil, "last burst")
+		}
+		b.entries = nil
+		// when
+		s.Close()
+		// then
+		require.Len(t, b.entries, 1)
+		assert.Equal(t, "1 similar log entries were suppressed", b.entries[0].Message)
+		assert.Equal(t, WarnLevel, b.entries[0].Level)
+		assert.Empty(t, s.counters)
+	})
+
+	t.Run("panic entries are not sampled", func(t *testing.T) {
+		// given
+		b.entries = nil
+		// when
+		for i := 0; i < 5; i++ {
+			assert.Panics(t, func() {
+				Panic(nil, nil, "panic")
+			})
+		}
+		// then
+		assert.Len(t, b.entries, 5)
+	})
+}
+
+func TestSamplerWithoutThereafter(t *testing.T) {
+	// given
+	s := NewSampler(time.Minute, 1, 0)
+	defer s.Close()
+	// when
+	sampled1, _ := s.sample(ErrorLevel, "failure", nil)
+	sampled2, _ := s.sample(ErrorLevel, "failure", nil)
+	sampled3, _ := s.sample(WarnLevel, "failure", nil)
+	// then
+	assert.True(t, sampled1)
+	assert.False(t, sampled2)
+	assert.True(t, sampled3)
+}
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fabric8-services/fabric8-common/configuration"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NewMultiBackend returns a Backend which writes each entry to all the given backends (or sinks)
+// which enable its level, e.g. a logrus backend writing JSON entries at the debug level to a file
+// and another one writing text entries at the warning level to os.Stderr.
+// When the level is changed at runtime (see SetLevel) to a level which is more verbose than all the sinks,
+// all the sinks which support level changes are set at this level. Otherwise, they are set back to their
+// initial level.
+func NewMultiBackend(backends ...Backend) Backend {
+	b := &multiBackend{
+		backends: backends,
+		initial:  make([]Level, len(backends)),
+	}
+	for i, s := range backends {
+		b.initial[i] = backendLevel(s)
+	}
+	return b
+}
+
+type multiBackend struct {
+	mu       sync.RWMutex
+	backends []Backend
+	initial  []Level
+}
+
+func (b *multiBackend) Enabled(level Level) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.backends {
+		if s.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *multiBackend) SetLevel(level Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	max := PanicLevel
+	for _, lvl := range b.initial {
+		if lvl > max {
+			max = lvl
+		}
+	}
+	for i, s := range b.backends {
+		if setter, ok := s.(LevelSetter); ok {
+			if level > max {
+				setter.SetLevel(level)
+			} else {
+				setter.SetLevel(b.initial[i])
+			}
+		}
+	}
+}
+
+func (b *multiBackend) Log(e Entry) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.backends {
+		if s.Enabled(e.Level) {
+			// each sink receives its own copy of the fields, in case it modifies them
+			fields := make(map[string]interface{}, len(e.Fields))
+			for k, v := range e.Fields {
+				fields[k] = v
+			}
+			s.Log(Entry{Time: e.Time, Level: e.Level, Message: e.Message, Fields: fields})
+		}
+	}
+}
+
+// InitializeLoggerWithConfig configures the log sinks according to the given configuration:
+// the console output (see `Registry.GetLogOutput`, `Registry.GetLogLevel` and `Registry.IsLogJSON`)
+// and the rotating log file (see `Registry.GetLogFilePath` and the other `Registry.GetLogFile*` settings).
+// Returns the log file, if any, so it can be closed when the service stops.
+//
+// The configuration supports at most two sinks, each with its own level and format: the console and a single
+// rotating file. A list of sinks (e.g. several files at different levels) cannot be configured, since all the
+// settings of the registry can also be set with environment variables, which cannot express such a list.
+// Instead, the services which need more sinks can create them with NewRotatingFile and NewLogrusBackend (or any
+// other Backend), combine them with NewMultiBackend and install the result with SetBackend.
+func InitializeLoggerWithConfig(config *configuration.Registry) (io.Closer, error) {
+	loggers := []*log.Logger{}
+	var out io.Writer
+	switch strings.ToLower(config.GetLogOutput()) {
+	case "stdout", "":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	case "none":
+	default:
+		return nil, fmt.Errorf("invalid log output: '%s'", config.GetLogOutput())
+	}
+	if out != nil {
+		lvl, err := log.ParseLevel(config.GetLogLevel())
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level: '%s'", config.GetLogLevel())
+		}
+		loggers = append(loggers, newLogrusLogger(lvl, config.IsLogJSON(), out))
+	}
+	var file *RotatingFile
+	if path := config.GetLogFilePath(); path != "" {
+		lvl, err := log.ParseLevel(config.GetLogFileLevel())
+		if err != nil {
+			return nil, fmt.Errorf("invalid log file level: '%s'", config.GetLogFileLevel())
+		}
+		file, err = NewRotatingFile(path,
+			WithMaxSize(int64(config.GetLogFileMaxSize())*1024*1024),
+			WithMaxBackups(config.GetLogFileMaxBackups()),
+			WithMaxAge(config.GetLogFileMaxAge()),
+			WithRotationInterval(config.GetLogFileRotationInterval()))
+		if err != nil {
+			return nil, err
+		}
+		loggers = append(loggers, newLogrusLogger(lvl, config.IsLogFileJSON(), file))
+	}
+	switch len(loggers) {
+	case 0:
+		logger = newLogrusLogger(log.PanicLevel, config.IsLogJSON(), ioutil.Discard)
+		SetBackend(NewLogrusBackend(logger))
+	case 1:
+		logger = loggers[0]
+		SetBackend(NewLogrusBackend(logger))
+	default:
+		logger = loggers[0]
+		sinks := make([]Backend, len(loggers))
+		for i, l := range loggers {
+			sinks[i] = NewLogrusBackend(l)
+		}
+		SetBackend(NewMultiBackend(sinks...))
+	}
+	if file == nil {
+		return nil, nil
+	}
+	return file, nil
+}
@@ -0,0 +1,90 @@
+package log_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/configuration"
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/log/logtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiBackend(t *testing.T) {
+	// given
+	debug := logtest.NewBackend(log.DebugLevel)
+	warn := logtest.NewBackend(log.WarnLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(log.NewMultiBackend(debug, warn))
+
+	t.Run("levels", func(t *testing.T) {
+		// given
+		debug.Reset()
+		warn.Reset()
+		// when
+		log.Debug(nil, nil, "debug")
+		log.Warn(nil, nil, "warn")
+		// then
+		require.Len(t, debug.Entries(), 2)
+		require.Len(t, warn.Entries(), 1)
+		assert.Equal(t, "warn", warn.LastEntry().Message)
+	})
+
+	t.Run("runtime level changes", func(t *testing.T) {
+		// given
+		defer log.ResetLevels()
+		info := logtest.NewBackend(log.InfoLevel)
+		log.SetBackend(log.NewMultiBackend(info, warn))
+		info.Reset()
+		warn.Reset()
+		// when
+		require.NoError(t, log.SetLevel(log.DebugLevel, 0))
+		log.Debug(nil, nil, "debug")
+		// then
+		assert.Len(t, info.Entries(), 1)
+		assert.Len(t, warn.Entries(), 1)
+		// when
+		log.ResetLevels()
+		log.Debug(nil, nil, "debug")
+		log.Info(nil, nil, "info")
+		// then
+		assert.Len(t, info.Entries(), 2)
+		assert.Len(t, warn.Entries(), 1)
+	})
+}
+
+func TestInitializeLoggerWithConfig(t *testing.T) {
+	// given
+	defer log.SetBackend(log.CurrentBackend())
+	dir, err := ioutil.TempDir("", "logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	configFile := filepath.Join(dir, "config.yaml")
+	config := fmt.Sprintf("log:\n  level: error\n  output: stderr\n  file:\n    path: %s\n    level: debug\n", path)
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(config), 0644))
+	c, err := configuration.New(configFile)
+	require.NoError(t, err)
+	// when
+	closer, err := log.InitializeLoggerWithConfig(c)
+	require.NoError(t, err)
+	log.Debug(context.Background(), map[string]interface{}{"foo": "bar"}, "debug")
+	require.NoError(t, closer.Close())
+	// then
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	fields := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(content, &fields))
+	assert.Equal(t, "debug", fields["msg"])
+	assert.Equal(t, "bar", fields["foo"])
+	// the console sink is at the error level
+	assert.Equal(t, "error", log.Logger().Level.String())
+	assert.True(t, log.CurrentBackend().Enabled(log.DebugLevel))
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// SlogPanicLevel the slog level used to write the entries logged with the Panic function,
+// since slog has no such level.
+const SlogPanicLevel = slog.LevelError + 4
+
+// NewSlogBackend returns a Backend which writes the log entries with the given `log/slog` logger.
+func NewSlogBackend(logger *slog.Logger) Backend {
+	return &slogBackend{logger: logger}
+}
+
+type slogBackend struct {
+	logger *slog.Logger
+}
+
+func (b *slogBackend) Enabled(level Level) bool {
+	return b.logger.Enabled(context.Background(), slogLevel(level))
+}
+
+func (b *slogBackend) Log(e Entry) {
+	r := slog.NewRecord(e.Time, slogLevel(e.Level), e.Message, 0)
+	// sort the attributes so the output is stable
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.AddAttrs(slog.Any(k, e.Fields[k]))
+	}
+	b.logger.Handler().Handle(context.Background(), r)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case PanicLevel:
+		return SlogPanicLevel
+	case ErrorLevel:
+		return slog.LevelError
+	case WarnLevel:
+		return slog.LevelWarn
+	case InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
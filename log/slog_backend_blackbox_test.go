@@ -0,0 +1,31 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogBackend(t *testing.T) {
+	// given
+	buf := &bytes.Buffer{}
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(log.NewSlogBackend(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))))
+	// when
+	log.Debug(nil, nil, "debug")
+	log.Info(nil, map[string]interface{}{"foo": "bar"}, "info %d", 1)
+	// then
+	var fields map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &fields)
+	require.NoError(t, err)
+	assert.Equal(t, "INFO", fields["level"])
+	assert.Equal(t, "info 1", fields["msg"])
+	assert.Equal(t, "bar", fields["foo"])
+	assert.Equal(t, "log_test", fields["pkg"])
+}
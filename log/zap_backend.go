@@ -0,0 +1,57 @@
+package log
+
+import "sync/atomic"
+
+// SugaredLogger the subset of the methods of zap's `*zap.SugaredLogger` which are used
+// by the zap backend. Depending on this interface rather than on the zap package lets
+// the services which use zap provide their own logger without forcing this dependency
+// on the other services.
+type SugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Panicw(msg string, keysAndValues ...interface{})
+}
+
+// NewZapBackend returns a Backend which writes the log entries with the given zap sugared logger.
+// Since the sugared logger does not expose its level, the entries below the given level are
+// discarded by the backend itself.
+func NewZapBackend(logger SugaredLogger, level Level) Backend {
+	return &zapBackend{
+		logger: logger,
+		level:  uint32(level),
+	}
+}
+
+type zapBackend struct {
+	logger SugaredLogger
+	level  uint32
+}
+
+func (b *zapBackend) Enabled(level Level) bool {
+	return Level(atomic.LoadUint32(&b.level)) >= level
+}
+
+func (b *zapBackend) SetLevel(level Level) {
+	atomic.StoreUint32(&b.level, uint32(level))
+}
+
+func (b *zapBackend) Log(e Entry) {
+	keysAndValues := make([]interface{}, 0, 2*len(e.Fields))
+	for k, v := range e.Fields {
+		keysAndValues = append(keysAndValues, k, v)
+	}
+	switch e.Level {
+	case PanicLevel:
+		b.logger.Panicw(e.Message, keysAndValues...)
+	case ErrorLevel:
+		b.logger.Errorw(e.Message, keysAndValues...)
+	case WarnLevel:
+		b.logger.Warnw(e.Message, keysAndValues...)
+	case InfoLevel:
+		b.logger.Infow(e.Message, keysAndValues...)
+	default:
+		b.logger.Debugw(e.Message, keysAndValues...)
+	}
+}
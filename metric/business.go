@@ -0,0 +1,298 @@
+package metric
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherLabelValue the value recorded instead of the label values which are not in the allow-list of their label
+// (see Counter.AllowLabelValues and Timer.AllowLabelValues)
+const OtherLabelValue = "other"
+
+// defaults the subsystem and the configuration of the recorder created with Recorder, which are shared by
+// the business metrics (see NewCounter, NewTimer and NewGaugeFunc)
+var defaults = struct {
+	sync.RWMutex
+	service string
+	config  *recorderConfig
+}{}
+
+// setDefaults sets the subsystem and the configuration of the business metrics, and registers them again
+func setDefaults(service string, c *recorderConfig) {
+	defaults.Lock()
+	defaults.service = service
+	defaults.config = c
+	defaults.Unlock()
+	ResetBusinessMetrics()
+}
+
+// getDefaults returns the subsystem and the configuration of the business metrics, or false if the Recorder
+// was not created yet
+func getDefaults() (string, *recorderConfig, bool) {
+	defaults.RLock()
+	defer defaults.RUnlock()
+	return defaults.service, defaults.config, defaults.config != nil
+}
+
+// businessMetrics all the business metrics, so they can be reset
+var businessMetrics = struct {
+	sync.Mutex
+	metrics []*businessMetric
+}{}
+
+// ResetBusinessMetrics unregisters all the business metrics and discards their values, e.g. between two tests.
+// Their collectors are created and registered again on their next use (or immediately for the gauges), with the
+// configuration of the last call to Recorder. Until Recorder is called, the business metrics are not registered.
+func ResetBusinessMetrics() {
+	businessMetrics.Lock()
+	defer businessMetrics.Unlock()
+	for _, m := range businessMetrics.metrics {
+		m.reset()
+		if m.eager {
+			m.get()
+		}
+	}
+}
+
+// businessMetric the common part of the business metrics, whose collector is created and registered with the
+// configuration of the Recorder on their first use, so that the metrics can be declared in package variables
+type businessMetric struct {
+	mu           sync.Mutex
+	name         string
+	labels       []string
+	newCollector func(c *recorderConfig, opts prometheus.Opts) prometheus.Collector
+	// eager whether the collector is registered as soon as the Recorder is created, instead of on first use
+	eager bool
+	// allowedValues the allowed values by label name (see allowLabelValues)
+	allowedValues map[string]map[string]bool
+	collector     prometheus.Collector
+	registerer    prometheus.Registerer
+}
+
+func newBusinessMetric(name string, labels []string, eager bool, newCollector func(*recorderConfig, prometheus.Opts) prometheus.Collector) *businessMetric {
+	m := &businessMetric{
+		name:          name,
+		labels:        labels,
+		eager:         eager,
+		allowedValues: map[string]map[string]bool{},
+		newCollector:  newCollector,
+	}
+	businessMetrics.Lock()
+	defer businessMetrics.Unlock()
+	businessMetrics.metrics = append(businessMetrics.metrics, m)
+	return m
+}
+
+// get returns the collector of the metric, after creating and registering it if needed, or nil if the Recorder
+// was not created yet, in which case the values are discarded. If the collector could not be registered, then
+// the values are still recorded, but they are not exposed.
+func (m *businessMetric) get() prometheus.Collector {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.collector != nil {
+		return m.collector
+	}
+	service, c, ok := getDefaults()
+	if !ok {
+		return nil
+	}
+	collector := m.newCollector(c, prometheus.Opts{
+		Namespace:   c.namespace,
+		Subsystem:   service,
+		Name:        m.name,
+		Help:        strings.Replace(m.name, "_", " ", -1),
+		ConstLabels: c.constLabels,
+	})
+	registered, err := register(c.registerer, collector)
+	if err != nil {
+		log.Error(nil, map[string]interface{}{
+			"metric_name": prometheus.BuildFQName(c.namespace, service, m.name),
+			"err":         err,
+		}, "failed to register the prometheus metric")
+		m.collector = collector
+		return m.collector
+	}
+	m.collector = registered
+	m.registerer = c.registerer
+	return m.collector
+}
+
+func (m *businessMetric) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.registerer != nil {
+		m.registerer.Unregister(m.collector)
+	}
+	m.collector = nil
+	m.registerer = nil
+}
+
+// allowLabelValues restricts the values of the label with the given name, in order to bound the cardinality
+// of the metric: the other values are recorded as OtherLabelValue
+func (m *businessMetric) allowLabelValues(label string, values ...string) {
+	known := false
+	for _, l := range m.labels {
+		if l == label {
+			known = true
+			break
+		}
+	}
+	if !known {
+		log.Error(nil, map[string]interface{}{
+			"metric_name": m.name,
+			"labels":      m.labels,
+			"label":       label,
+		}, "unknown label of the metric")
+		return
+	}
+	allowed := make(map[string]bool, len(values))
+	for _, v := range values {
+		allowed[v] = true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allowedValues[label] = allowed
+}
+
+// labelValues returns the given label values, in which the values which are not allowed are replaced with
+// OtherLabelValue, or false if the number of values does not match the number of labels
+func (m *businessMetric) labelValues(values []string) ([]string, bool) {
+	if len(values) != len(m.labels) {
+		log.Error(nil, map[string]interface{}{
+			"metric_name":  m.name,
+			"labels":       m.labels,
+			"label_values": values,
+		}, "invalid number of label values")
+		return nil, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]string, len(values))
+	for i, v := range values {
+		if allowed, found := m.allowedValues[m.labels[i]]; found && !allowed[v] {
+			v = OtherLabelValue
+		}
+		result[i] = v
+	}
+	return result, true
+}
+
+// Counter a business counter, e.g. the number of work items created by type
+type Counter struct {
+	*businessMetric
+}
+
+// NewCounter returns a new counter with the given name and label names, registered in the subsystem of the
+// Recorder on its first use. The values recorded before the Recorder is created are discarded.
+//
+// Usage example:
+//
+//	var workItemsCreated = metric.NewCounter("workitems_created_total", "type").AllowLabelValues("type", "bug", "task")
+//	...
+//	workItemsCreated.Inc("bug")
+func NewCounter(name string, labels ...string) *Counter {
+	return &Counter{
+		businessMetric: newBusinessMetric(name, labels, false, func(_ *recorderConfig, opts prometheus.Opts) prometheus.Collector {
+			return prometheus.NewCounterVec(prometheus.CounterOpts(opts), labels)
+		}),
+	}
+}
+
+// AllowLabelValues restricts the values of the label with the given name, in order to bound the cardinality of the
+// counter: the other values are recorded as OtherLabelValue. By default, the values of the labels are not restricted.
+func (c *Counter) AllowLabelValues(label string, values ...string) *Counter {
+	c.allowLabelValues(label, values...)
+	return c
+}
+
+// Inc increments the counter with the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds the given (non-negative) value to the counter with the given label values
+func (c *Counter) Add(v float64, values ...string) {
+	if values, ok := c.labelValues(values); ok {
+		if vec, ok := c.get().(*prometheus.CounterVec); ok {
+			vec.WithLabelValues(values...).Add(v)
+		}
+	}
+}
+
+// Timer a business histogram of durations, in seconds
+type Timer struct {
+	*businessMetric
+}
+
+// NewTimer returns a new histogram of durations with the given name and label names, registered in the subsystem
+// of the Recorder on its first use, with the buckets configured with WithRequestDurationBucket. The durations
+// observed before the Recorder is created are discarded.
+//
+// Usage example:
+//
+//	var workItemsSearch = metric.NewTimer("workitems_search_duration_seconds", "space_template")
+//	...
+//	defer workItemsSearch.Start("scrum")()
+func NewTimer(name string, labels ...string) *Timer {
+	return &Timer{
+		businessMetric: newBusinessMetric(name, labels, false, func(c *recorderConfig, opts prometheus.Opts) prometheus.Collector {
+			return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace:   opts.Namespace,
+				Subsystem:   opts.Subsystem,
+				Name:        opts.Name,
+				Help:        opts.Help,
+				ConstLabels: opts.ConstLabels,
+				Buckets:     c.reqDuration,
+			}, labels)
+		}),
+	}
+}
+
+// AllowLabelValues restricts the values of the label with the given name, in order to bound the cardinality of the
+// timer: the other values are recorded as OtherLabelValue. By default, the values of the labels are not restricted.
+func (t *Timer) AllowLabelValues(label string, values ...string) *Timer {
+	t.allowLabelValues(label, values...)
+	return t
+}
+
+// Start starts timing an operation, and returns the function to call (e.g. with `defer`) once the operation is
+// done to observe its duration with the given label values
+func (t *Timer) Start(values ...string) func() {
+	startTime := time.Now()
+	return func() {
+		t.Observe(time.Since(startTime), values...)
+	}
+}
+
+// Observe observes the given duration with the given label values
+func (t *Timer) Observe(d time.Duration, values ...string) {
+	if values, ok := t.labelValues(values); ok {
+		if vec, ok := t.get().(*prometheus.HistogramVec); ok {
+			vec.WithLabelValues(values...).Observe(d.Seconds())
+		}
+	}
+}
+
+// GaugeFunc a business gauge whose value is obtained by calling a function when the metrics are collected
+type GaugeFunc struct {
+	*businessMetric
+}
+
+// NewGaugeFunc returns a new gauge with the given name, whose value is obtained by calling the given function
+// (which must be concurrency-safe) when the metrics are collected. Contrary to the other business metrics, the
+// gauge is registered in the subsystem of the Recorder as soon as it is created (or immediately if the Recorder
+// was already created).
+func NewGaugeFunc(name string, f func() float64) *GaugeFunc {
+	g := &GaugeFunc{
+		businessMetric: newBusinessMetric(name, nil, true, func(_ *recorderConfig, opts prometheus.Opts) prometheus.Collector {
+			return prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts), f)
+		}),
+	}
+	g.get()
+	return g
+}
@@ -0,0 +1,118 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// not parallel: the business metrics share the configuration of the last Recorder
+func TestBusinessMetrics(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given business metrics declared before the Recorder is created
+	setDefaults("", nil)
+	workItemsCreated := NewCounter("workitems_created_total", "workitem_type").AllowLabelValues("workitem_type", "bug", "task")
+	workItemsDeleted := NewCounter("workitems_deleted_total", "workitem_type")
+	workItemsSearch := NewTimer("workitems_search_duration_seconds", "space_template")
+	NewGaugeFunc("spaces", func() float64 { return 3 })
+	workItemsCreated.Inc("bug")
+	workItemsSearch.Observe(time.Second, "scrum")
+	registry := prometheus.NewRegistry()
+	Recorder("TestBusiness", WithRegisterer(registry), WithConstLabels(prometheus.Labels{"cluster": "c1"}))
+
+	t.Run("values before the Recorder are discarded", func(t *testing.T) {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+		for _, f := range families {
+			assert.NotContains(t, []string{"workitems_created_total", "workitems_search_duration_seconds", "spaces"}, f.GetName())
+		}
+		assert.Nil(t, findMetric(t, registry, "TestBusiness_workitems_created_total", map[string]string{
+			"cluster": "c1", "workitem_type": "bug",
+		}))
+		assert.Nil(t, findMetric(t, registry, "TestBusiness_workitems_search_duration_seconds", map[string]string{
+			"cluster": "c1", "space_template": "scrum",
+		}))
+	})
+
+	t.Run("counter", func(t *testing.T) {
+		// when
+		workItemsCreated.Inc("bug")
+		workItemsCreated.Add(2, "bug")
+		// then
+		assert.Equal(t, float64(3), gatherCounter(t, registry, "TestBusiness_workitems_created_total", map[string]string{
+			"cluster": "c1", "workitem_type": "bug",
+		}))
+	})
+
+	t.Run("label values not allowed", func(t *testing.T) {
+		// when
+		workItemsCreated.Inc("epic")
+		workItemsCreated.Inc("feature")
+		// then
+		assert.Equal(t, float64(2), gatherCounter(t, registry, "TestBusiness_workitems_created_total", map[string]string{
+			"cluster": "c1", "workitem_type": OtherLabelValue,
+		}))
+	})
+
+	t.Run("label values allowed in another metric", func(t *testing.T) {
+		// when
+		workItemsDeleted.Inc("epic")
+		// then the allow-list of the other metric does not apply
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestBusiness_workitems_deleted_total", map[string]string{
+			"cluster": "c1", "workitem_type": "epic",
+		}))
+	})
+
+	t.Run("unknown label in the allow-list", func(t *testing.T) {
+		// when
+		workItemsDeleted.AllowLabelValues("space_template", "scrum")
+		workItemsDeleted.Inc("feature")
+		// then
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestBusiness_workitems_deleted_total", map[string]string{
+			"cluster": "c1", "workitem_type": "feature",
+		}))
+	})
+
+	t.Run("invalid number of label values", func(t *testing.T) {
+		// when/then no panic
+		workItemsCreated.Inc("bug", "scrum")
+	})
+
+	t.Run("timer", func(t *testing.T) {
+		// when
+		func() {
+			defer workItemsSearch.Start("scrum")()
+		}()
+		workItemsSearch.Observe(time.Second, "scrum")
+		// then
+		m := gatherMetric(t, registry, "TestBusiness_workitems_search_duration_seconds", map[string]string{
+			"cluster": "c1", "space_template": "scrum",
+		})
+		require.NotNil(t, m)
+		assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+		assert.True(t, m.GetHistogram().GetSampleSum() >= 1)
+	})
+
+	t.Run("gauge func", func(t *testing.T) {
+		assert.Equal(t, float64(3), gatherGauge(t, registry, "TestBusiness_spaces", map[string]string{"cluster": "c1"}))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		// when
+		ResetBusinessMetrics()
+		workItemsCreated.Inc("task")
+		// then
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestBusiness_workitems_created_total", map[string]string{
+			"cluster": "c1", "workitem_type": "task",
+		}))
+		assert.Nil(t, findMetric(t, registry, "TestBusiness_workitems_created_total", map[string]string{
+			"cluster": "c1", "workitem_type": "bug",
+		}))
+		assert.Equal(t, float64(3), gatherGauge(t, registry, "TestBusiness_spaces", map[string]string{"cluster": "c1"}))
+	})
+}
@@ -0,0 +1,222 @@
+package metric
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/migration"
+
+	"github.com/jinzhu/gorm"
+	errs "github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryStartTimeKey the key of the start time of a query in the gorm scope
+const queryStartTimeKey = "metric:query_start_time"
+
+// gormOperations the gorm callback processors whose queries are observed, by operation
+var gormOperations = map[string]func(*gorm.Callback) *gorm.CallbackProcessor{
+	"create":    (*gorm.Callback).Create,
+	"query":     (*gorm.Callback).Query,
+	"update":    (*gorm.Callback).Update,
+	"delete":    (*gorm.Callback).Delete,
+	"row_query": (*gorm.Callback).RowQuery,
+}
+
+// DBRecorder records the Prometheus metrics related to a database: the statistics of its connection pool,
+// the duration of the queries executed with gorm and the migrations of its schema.
+type DBRecorder struct {
+	db                *gorm.DB
+	queryDuration     *prometheus.HistogramVec
+	schemaVersion     prometheus.Gauge
+	migrationDuration prometheus.Gauge
+}
+
+// NewDBRecorder returns a new recorder of the metrics of the given database, registered in the given
+// `service` subsystem. The recorder registers gorm callbacks on the database to observe the duration
+// of the queries by `table` and `operation` (create, query, update, delete or row_query), which replace
+// the callbacks of the previous recorder of the same database, if any.
+// Returns an error if the metrics could not be registered.
+func NewDBRecorder(service string, db *gorm.DB, options ...RecorderOption) (*DBRecorder, error) {
+	c := newRecorderConfig(options...)
+	r := &DBRecorder{db: db}
+	if _, err := register(c.registerer, newDBStatsCollector(db.DB(), c, service)); err != nil {
+		return nil, err
+	}
+	queryDuration, err := register(c.registerer,
+		prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   c.namespace,
+				Subsystem:   service,
+				Name:        "db_query_duration_seconds",
+				Help:        "Bucketed histogram of the duration (s) of the database queries.",
+				Buckets:     c.queryDuration,
+				ConstLabels: c.constLabels,
+			},
+			[]string{"table", "operation"}))
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if r.queryDuration, ok = queryDuration.(*prometheus.HistogramVec); !ok {
+		return nil, errs.Errorf("metric '%s' is already registered with another type", prometheus.BuildFQName(c.namespace, service, "db_query_duration_seconds"))
+	}
+	if r.schemaVersion, err = registerGauge(c, service, "db_schema_version",
+		"Current version of the database schema."); err != nil {
+		return nil, err
+	}
+	if r.migrationDuration, err = registerGauge(c, service, "db_migration_duration_seconds",
+		"Duration (s) of the last migration of the database schema."); err != nil {
+		return nil, err
+	}
+	r.registerCallbacks()
+	log.Info(nil, map[string]interface{}{
+		"namespace": c.namespace,
+		"subsystem": service,
+	}, "database metrics registered successfully")
+	return r, nil
+}
+
+func registerGauge(c *recorderConfig, service, name, help string) (prometheus.Gauge, error) {
+	g, err := register(c.registerer,
+		prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   c.namespace,
+				Subsystem:   service,
+				Name:        name,
+				Help:        help,
+				ConstLabels: c.constLabels,
+			}))
+	if err != nil {
+		return nil, err
+	}
+	result, ok := g.(prometheus.Gauge)
+	if !ok {
+		return nil, errs.Errorf("metric '%s' is already registered with another type", prometheus.BuildFQName(c.namespace, service, name))
+	}
+	return result, nil
+}
+
+// registerCallbacks registers the gorm callbacks which observe the duration of the queries. If the callbacks
+// were already registered on the database by another recorder, then they are replaced, so the queries are
+// observed once, by the latest recorder.
+func (r *DBRecorder) registerCallbacks() {
+	callback := r.db.Callback()
+	for operation, processor := range gormOperations {
+		before, after := "metric:before_"+operation, "metric:after_"+operation
+		if processor(callback).Get(after) != nil {
+			processor(callback).Replace(after, r.observeQuery(operation))
+			continue
+		}
+		// each registration needs its own processor, since the processor stores the name and position of the callback
+		processor(callback).Before("gorm:"+operation).Register(before, startQuery)
+		processor(callback).After("gorm:"+operation).Register(after, r.observeQuery(operation))
+	}
+}
+
+// startQuery stores the start time of the query in the gorm scope
+func startQuery(scope *gorm.Scope) {
+	scope.InstanceSet(queryStartTimeKey, time.Now())
+}
+
+// observeQuery returns the gorm callback which observes the duration of the queries of the given operation
+func (r *DBRecorder) observeQuery(operation string) func(*gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, found := scope.InstanceGet(queryStartTimeKey)
+		if !found {
+			return
+		}
+		startTime, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := scope.TableName()
+		if table == "" {
+			// e.g. raw queries
+			table = "unknown"
+		}
+		r.queryDuration.WithLabelValues(table, operation).Observe(time.Since(startTime).Seconds())
+	}
+}
+
+// Migrate migrates the database with migration.Migrate, and records the duration of the migration along
+// with the resulting version of the schema (even if the migration failed). Returns the error of the migration.
+func (r *DBRecorder) Migrate(catalog string, migrateData migration.MigrateData) error {
+	startTime := time.Now()
+	err := migration.Migrate(r.db.DB(), catalog, migrateData)
+	r.migrationDuration.Set(time.Since(startTime).Seconds())
+	if verr := r.RecordSchemaVersion(catalog); verr != nil {
+		log.Warn(nil, map[string]interface{}{
+			"catalog": catalog,
+			"err":     verr,
+		}, "failed to record the version of the database schema")
+	}
+	return err
+}
+
+// RecordSchemaVersion records the current version of the database schema, e.g. when the
+// database was migrated by another instance of the service
+func (r *DBRecorder) RecordSchemaVersion(catalog string) error {
+	version, err := migration.CurrentVersion(r.db.DB(), catalog)
+	if err != nil {
+		return err
+	}
+	r.schemaVersion.Set(float64(version))
+	return nil
+}
+
+// dbStatsCollector collects the statistics of the connection pool of a database (see sql.DBStats)
+type dbStatsCollector struct {
+	db                 *sql.DB
+	maxOpenConnections *prometheus.Desc
+	openConnections    *prometheus.Desc
+	inUse              *prometheus.Desc
+	idle               *prometheus.Desc
+	waitCount          *prometheus.Desc
+	waitDuration       *prometheus.Desc
+	maxIdleClosed      *prometheus.Desc
+	maxLifetimeClosed  *prometheus.Desc
+}
+
+func newDBStatsCollector(db *sql.DB, c *recorderConfig, service string) *dbStatsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(c.namespace, service, name), help, nil, c.constLabels)
+	}
+	return &dbStatsCollector{
+		db:                 db,
+		maxOpenConnections: desc("db_max_open_connections", "Maximum number of open connections to the database."),
+		openConnections:    desc("db_open_connections", "Number of established connections to the database, both in use and idle."),
+		inUse:              desc("db_in_use_connections", "Number of connections currently in use."),
+		idle:               desc("db_idle_connections", "Number of idle connections."),
+		waitCount:          desc("db_wait_count_total", "Total number of connections waited for."),
+		waitDuration:       desc("db_wait_duration_seconds_total", "Total time (s) blocked waiting for a new connection."),
+		maxIdleClosed:      desc("db_max_idle_closed_total", "Total number of connections closed due to the maximum number of idle connections."),
+		maxLifetimeClosed:  desc("db_max_lifetime_closed_total", "Total number of connections closed due to the maximum connection lifetime."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpenConnections
+	ch <- c.openConnections
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxLifetimeClosed
+}
+
+// Collect implements prometheus.Collector
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpenConnections, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.openConnections, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
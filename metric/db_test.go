@@ -0,0 +1,150 @@
+package metric
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/internal"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	_ "github.com/lib/pq" // need to import postgres driver
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDBStatsCollector(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given no connection is established until the first query
+	db, err := sql.Open("postgres", "host=localhost sslmode=disable")
+	require.NoError(t, err)
+	defer db.Close()
+	reg := prometheus.NewRegistry()
+	c := newRecorderConfig(WithNamespace("test"), WithConstLabels(prometheus.Labels{"db": "main"}))
+	// when
+	err = reg.Register(newDBStatsCollector(db, c, "svc"))
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), gatherGauge(t, reg, "test_svc_db_open_connections", map[string]string{"db": "main"}))
+}
+
+type DBRecorderTestSuite struct {
+	internal.DBTestSuite
+}
+
+func TestDBRecorder(t *testing.T) {
+	suite.Run(t, &DBRecorderTestSuite{internal.NewDBTestSuiteSuite()})
+}
+
+type metricsTestEntity struct {
+	ID   int    `gorm:"primary_key"`
+	Name string `gorm:"column:name"`
+}
+
+func (e metricsTestEntity) TableName() string {
+	return "metrics_test_entities"
+}
+
+func (s *DBRecorderTestSuite) TestQueryDuration() {
+	// given
+	reg := prometheus.NewRegistry()
+	_, err := NewDBRecorder("svc", s.DB, WithRegisterer(reg))
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.DB.AutoMigrate(&metricsTestEntity{}).Error)
+	defer s.DB.DropTable(&metricsTestEntity{})
+	// when
+	e := metricsTestEntity{Name: "foo"}
+	require.NoError(s.T(), s.DB.Create(&e).Error)
+	var entities []metricsTestEntity
+	require.NoError(s.T(), s.DB.Where("name = ?", "foo").Find(&entities).Error)
+	require.NoError(s.T(), s.DB.Delete(&e).Error)
+	// then
+	for _, operation := range []string{"create", "query", "delete"} {
+		m := gatherMetric(s.T(), reg, "svc_db_query_duration_seconds", map[string]string{
+			"table":     "metrics_test_entities",
+			"operation": operation,
+		})
+		require.NotNil(s.T(), m, "missing histogram for operation '%s'", operation)
+		assert.Equal(s.T(), uint64(1), m.GetHistogram().GetSampleCount(), "operation '%s'", operation)
+	}
+	assert.True(s.T(), gatherGauge(s.T(), reg, "svc_db_open_connections", nil) >= 1)
+}
+
+func (s *DBRecorderTestSuite) TestQueryDurationWithSeveralRecorders() {
+	// given
+	reg1 := prometheus.NewRegistry()
+	_, err := NewDBRecorder("svc", s.DB, WithRegisterer(reg1))
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.DB.AutoMigrate(&metricsTestEntity{}).Error)
+	defer s.DB.DropTable(&metricsTestEntity{})
+	require.NoError(s.T(), s.DB.Create(&metricsTestEntity{Name: "foo"}).Error)
+	// when
+	reg2 := prometheus.NewRegistry()
+	_, err = NewDBRecorder("svc", s.DB, WithRegisterer(reg2))
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.DB.Create(&metricsTestEntity{Name: "bar"}).Error)
+	// then each query is observed once, by the latest recorder
+	for _, reg := range []*prometheus.Registry{reg1, reg2} {
+		m := gatherMetric(s.T(), reg, "svc_db_query_duration_seconds", map[string]string{
+			"table":     "metrics_test_entities",
+			"operation": "create",
+		})
+		require.NotNil(s.T(), m)
+		assert.Equal(s.T(), uint64(1), m.GetHistogram().GetSampleCount())
+	}
+}
+
+func (s *DBRecorderTestSuite) TestMigrate() {
+	// given
+	reg := prometheus.NewRegistry()
+	r, err := NewDBRecorder("svc", s.DB, WithRegisterer(reg))
+	require.NoError(s.T(), err)
+
+	s.T().Run("migration OK", func(t *testing.T) {
+		// when
+		err := r.Migrate(s.DBName, migrateData{})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), gatherGauge(t, reg, "svc_db_schema_version", nil))
+		assert.True(t, gatherGauge(t, reg, "svc_db_migration_duration_seconds", nil) > 0)
+	})
+
+	s.T().Run("migration failed", func(t *testing.T) {
+		// when
+		err := r.Migrate(s.DBName, migrateData{"SELECT invalid"})
+		// then the version of the schema is unchanged
+		require.Error(t, err)
+		assert.Equal(t, float64(1), gatherGauge(t, reg, "svc_db_schema_version", nil))
+	})
+}
+
+// migrateData the migration data with a version table, an entity table and the given additional statements
+type migrateData []string
+
+func (d migrateData) Asset(name string) ([]byte, error) {
+	switch name {
+	case "000-bootstrap.sql":
+		return []byte("CREATE TABLE IF NOT EXISTS version (id bigserial primary key, updated_at timestamp with time zone not null default current_timestamp, version bigint not null)"), nil
+	case "001-create-tables.sql":
+		return []byte("CREATE TABLE metrics_migrations (id bigserial primary key)"), nil
+	}
+	var i int
+	if _, err := fmt.Sscanf(name, "%03d-extra.sql", &i); err != nil || i < 2 || i-2 >= len(d) {
+		return nil, fmt.Errorf("unknown asset: %s", name)
+	}
+	return []byte(d[i-2]), nil
+}
+
+func (d migrateData) AssetNameWithArgs() [][]string {
+	names := [][]string{
+		{"000-bootstrap.sql"},
+		{"001-create-tables.sql"},
+	}
+	for i := range d {
+		names = append(names, []string{fmt.Sprintf("%03d-extra.sql", i+2)})
+	}
+	return names
+}
@@ -0,0 +1,55 @@
+package metric
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const requestIDExemplarLabel = "request_id"
+
+// WithExemplars attaches an exemplar to the observations of the `request duration` histogram, i.e., the ID of
+// the trace in which the request was handled (`trace_id`), or else the ID of the request (`request_id`), so that
+// the slow buckets can be correlated with the traces and the log entries. Only the latest exemplar of each bucket
+// is kept, and the exemplars are only exposed in the OpenMetrics format (see OpenMetricsHandler).
+func WithExemplars() RecorderOption {
+	return func(c *recorderConfig) {
+		c.exemplars = true
+	}
+}
+
+// OpenMetricsHandler returns a handler which exposes the metrics of the given gatherer (or the default Prometheus
+// registry if nil) in the OpenMetrics text format, along with the exemplars of the histograms (see WithExemplars),
+// if the client accepts this format (e.g. Prometheus with the `exemplar-storage` feature enabled). Otherwise, the
+// metrics are exposed without the exemplars, in the format negotiated with the client.
+func OpenMetricsHandler(g prometheus.Gatherer) http.Handler {
+	if g == nil {
+		g = prometheus.DefaultGatherer
+	}
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// exemplarLabels returns the labels of the exemplar recorded in the given context, i.e., the ID of the current
+// trace, or else the ID of the request, or nil if the context contains neither. The labels of an exemplar must not
+// exceed `prometheus.ExemplarMaxRunes`: the generated request IDs (UUIDs) always fit, but the incoming ones can have
+// up to `requestid.MaxLength` characters, so they are truncated if needed.
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		return prometheus.Labels{"trace_id": span.SpanContext().TraceID.String()}
+	}
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return nil
+	}
+	max := prometheus.ExemplarMaxRunes - utf8.RuneCountInString(requestIDExemplarLabel)
+	if runes := []rune(id); len(runes) > max {
+		id = string(runes[:max])
+	}
+	return prometheus.Labels{requestIDExemplarLabel: id}
+}
@@ -0,0 +1,72 @@
+package metric
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/resource"
+	"github.com/fabric8-services/fabric8-common/tracing"
+
+	"github.com/goadesign/goa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExemplars(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	svc := goa.New("metric")
+	ctrl := svc.NewController(dummyCtrl)
+	registry := prometheus.NewRegistry()
+	r, err := NewRequestRecorder("TestExemplars", WithRegisterer(registry), WithExemplars(),
+		WithConstLabels(prometheus.Labels{"cluster": "c1"}), WithRequestDurationBucket([]float64{0.5, 10}))
+	require.NoError(t, err)
+	h := r.Middleware()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		rw.WriteHeader(http.StatusOK)
+		return nil
+	})
+	// a traced request
+	ctx, span := tracing.StartSpan(newRequestCtx(ctrl, getMethod), "dummy.show", tracing.SpanKindServer)
+	require.NoError(t, h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request))
+	span.End()
+	// a request which is only identified by its ID
+	ctx = requestid.NewContext(newRequestCtx(ctrl, postMethod), "req-1")
+	require.NoError(t, h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request))
+	// a request with the longest acceptable ID
+	longID := strings.Repeat("a", requestid.MaxLength)
+	ctx = requestid.NewContext(newRequestCtx(ctrl, http.MethodPatch), longID)
+	require.NoError(t, h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request))
+	handler := OpenMetricsHandler(registry)
+
+	t.Run("openmetrics", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1,text/plain;version=0.0.4;q=0.5")
+		handler.ServeHTTP(rw, req)
+		// then
+		assert.Contains(t, rw.Header().Get("Content-Type"), "application/openmetrics-text")
+		body := rw.Body.String()
+		assert.Contains(t, body, `TestExemplars_request_duration_seconds_bucket{cluster="c1",code="2xx",entity="dummy",method="get",le="0.5"} 1 # {trace_id="`+span.SpanContext().TraceID.String()+`"} `)
+		// the request ID as a fallback
+		assert.Contains(t, body, `TestExemplars_request_duration_seconds_bucket{cluster="c1",code="2xx",entity="dummy",method="post",le="0.5"} 1 # {request_id="req-1"} `)
+		// truncated to fit in the exemplar
+		assert.Contains(t, body, `TestExemplars_request_duration_seconds_bucket{cluster="c1",code="2xx",entity="dummy",method="patch",le="0.5"} 1 # {request_id="`+longID[:prometheus.ExemplarMaxRunes-len("request_id")]+`"} `)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		// when
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		handler.ServeHTTP(rw, req)
+		// then
+		assert.Contains(t, rw.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, rw.Body.String(), `TestExemplars_request_duration_seconds_bucket{cluster="c1",code="2xx",entity="dummy",method="get",le="0.5"} 1`+"\n")
+		assert.NotContains(t, rw.Body.String(), "# {")
+	})
+}
@@ -1,150 +1,369 @@
 package metric
 
 import (
+	"context"
+	"net/http"
 	"time"
 
 	"github.com/fabric8-services/fabric8-common/log"
+
+	errs "github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	namespace   = ""
-	subsystem   string
-	reqLabels   = []string{"method", "entity", "code"}
-	reqCnt      *prometheus.CounterVec
-	reqDuration *prometheus.HistogramVec
-	resSize     *prometheus.HistogramVec
-	reqSize     *prometheus.HistogramVec
+	reqLabels = []string{"method", "entity", "code"}
+	// routeLabels the labels of the metrics when the recorder is configured with WithRouteLabels
+	routeLabels = []string{"method", "action", "route", "code"}
 )
 
+// RecorderOption a function to configure a given recorder config
+type RecorderOption func(c *recorderConfig)
+
 // BucketOption a function to configure a given buckets config
-type BucketOption func(c *bucketsConfig)
+type BucketOption = RecorderOption
 
 // WithRequestDurationBucket configures the bucket for the `request duration` metrics
 func WithRequestDurationBucket(bucket []float64) BucketOption {
-	return func(c *bucketsConfig) {
+	return func(c *recorderConfig) {
 		c.reqDuration = bucket
 	}
 }
 
 // WithRequestSizeBucket configures the bucket for the `request size` metrics
 func WithRequestSizeBucket(bucket []float64) BucketOption {
-	return func(c *bucketsConfig) {
+	return func(c *recorderConfig) {
 		c.reqSize = bucket
 	}
 }
 
 // WithResponseSizeBucket configures the bucket for the `response size` metrics
 func WithResponseSizeBucket(bucket []float64) BucketOption {
-	return func(c *bucketsConfig) {
+	return func(c *recorderConfig) {
 		c.resSize = bucket
 	}
 }
 
+// WithQueryDurationBucket configures the bucket for the `query duration` metrics of the DBRecorder
+func WithQueryDurationBucket(bucket []float64) BucketOption {
+	return func(c *recorderConfig) {
+		c.queryDuration = bucket
+	}
+}
+
+// WithRouteLabels labels the metrics by `method`, `action` (e.g. "space.show") and `route` pattern
+// (e.g. "/api/spaces/:spaceID") instead of `method` and `entity` (e.g. "space"), so the latency of
+// each endpoint can be observed. Contrary to the `entity` label, which is only set for the controllers
+// named "...Controller", the `action` and `route` labels are set for all the requests.
+func WithRouteLabels() RecorderOption {
+	return func(c *recorderConfig) {
+		c.routeLabels = true
+	}
+}
+
+// WithStatusCodeLabel labels the metrics by the exact status code of the responses (e.g. "201")
+// instead of their class (e.g. "2xx")
+func WithStatusCodeLabel() RecorderOption {
+	return func(c *recorderConfig) {
+		c.statusCode = true
+	}
+}
+
+// WithRouteFunc sets the function which returns the `route` label of the requests handled by the
+// plain `net/http` handlers (see Handler). By default, the route is the path of the request, which
+// is only suitable for the handlers of paths without parameters.
+func WithRouteFunc(f func(*http.Request) string) RecorderOption {
+	return func(c *recorderConfig) {
+		c.routeFunc = f
+	}
+}
+
+// WithRegisterer registers the metrics of the recorder with the given registerer
+// instead of the default Prometheus registry
+func WithRegisterer(r prometheus.Registerer) RecorderOption {
+	return func(c *recorderConfig) {
+		c.registerer = r
+	}
+}
+
+// WithNamespace sets the namespace of the metrics of the recorder (by default, the metrics have no namespace)
+func WithNamespace(namespace string) RecorderOption {
+	return func(c *recorderConfig) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds the given constant labels to the metrics of the recorder (e.g. the cluster name)
+func WithConstLabels(labels prometheus.Labels) RecorderOption {
+	return func(c *recorderConfig) {
+		if c.constLabels == nil {
+			c.constLabels = prometheus.Labels{}
+		}
+		for k, v := range labels {
+			c.constLabels[k] = v
+		}
+	}
+}
+
 type buckets []float64
 
 type bucketsConfig struct {
-	reqDuration buckets
-	reqSize     buckets
-	resSize     buckets
+	reqDuration   buckets
+	reqSize       buckets
+	resSize       buckets
+	queryDuration buckets
 }
 
-func registerMetrics(service string, opts ...BucketOption) {
-	subsystem = service
+type recorderConfig struct {
+	bucketsConfig
+	routeLabels bool
+	statusCode  bool
+	routeFunc   func(*http.Request) string
+	registerer  prometheus.Registerer
+	namespace   string
+	constLabels prometheus.Labels
+	exemplars   bool
+}
 
-	buckets := &bucketsConfig{
-		reqDuration: prometheus.ExponentialBuckets(0.05, 2, 8),
-		reqSize:     []float64{1000, 5000, 10000, 20000, 30000, 40000, 50000},
-		resSize:     []float64{1000, 5000, 10000, 20000, 30000, 40000, 50000},
+func newRecorderConfig(opts ...RecorderOption) *recorderConfig {
+	c := &recorderConfig{
+		bucketsConfig: bucketsConfig{
+			reqDuration: prometheus.ExponentialBuckets(0.05, 2, 8),
+			reqSize:     []float64{1000, 5000, 10000, 20000, 30000, 40000, 50000},
+			resSize:     []float64{1000, 5000, 10000, 20000, 30000, 40000, 50000},
+		},
+		routeFunc: func(req *http.Request) string {
+			return req.URL.Path
+		},
+		registerer: prometheus.DefaultRegisterer,
 	}
 	for _, opt := range opts {
-		opt(buckets)
+		opt(c)
+	}
+	return c
+}
+
+// labels returns the names of the labels of the metrics
+func (c *recorderConfig) labels() []string {
+	if c.routeLabels {
+		return routeLabels
+	}
+	return reqLabels
+}
+
+// inFlightLabels returns the names of the labels of the in-flight requests gauge,
+// i.e., the labels of the other metrics except the status code
+func (c *recorderConfig) inFlightLabels() []string {
+	labels := c.labels()
+	return labels[:len(labels)-1]
+}
+
+// RequestRecorder records the Prometheus metrics related to the HTTP requests and responses.
+// Each recorder owns its collectors, so several recorders can be created in the same process,
+// with different registerers, namespaces or services. The recorders created with the same service
+// and namespace on the same registerer share their collectors, provided that they have the same labels.
+type RequestRecorder struct {
+	config      *recorderConfig
+	reqCnt      *prometheus.CounterVec
+	reqDuration *prometheus.HistogramVec
+	resSize     *prometheus.HistogramVec
+	reqSize     *prometheus.HistogramVec
+	inFlight    *prometheus.GaugeVec
+	errCnt      *prometheus.CounterVec
+}
+
+// NewRequestRecorder returns a new recorder whose metrics are registered in the given `service`
+// subsystem. Returns an error if the metrics could not be registered, e.g. because metrics
+// with the same names but other labels were already registered.
+func NewRequestRecorder(service string, options ...RecorderOption) (*RequestRecorder, error) {
+	r, err := newRequestRecorder(service, options...)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to register the request metrics of the '%s' subsystem "+
+			"(the recorders of the same subsystem must have the same label scheme, with or without WithRouteLabels, "+
+			"and the same constant labels)", service)
 	}
+	return r, nil
+}
+
+func newRequestRecorder(service string, options ...RecorderOption) (*RequestRecorder, error) {
+	c := newRecorderConfig(options...)
+	r := &RequestRecorder{config: c}
+	labels := c.labels()
 
-	reqCnt = Register(
+	reqCnt, err := register(c.registerer,
 		prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "requests_total",
-				Help:      "Counter of requests received into the system.",
+				Namespace:   c.namespace,
+				Subsystem:   service,
+				Name:        "requests_total",
+				Help:        "Counter of requests received into the system.",
+				ConstLabels: c.constLabels,
 			},
-			reqLabels),
-		"requests_total").(*prometheus.CounterVec)
-	reqDuration = Register(
-		prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "request_duration_seconds",
-				Help:      "Bucketed histogram of processing time (s) of requests.",
-				Buckets:   buckets.reqDuration,
+			labels))
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if r.reqCnt, ok = reqCnt.(*prometheus.CounterVec); !ok {
+		return nil, errs.Errorf("metric '%s' is already registered with another type", prometheus.BuildFQName(c.namespace, service, "requests_total"))
+	}
+	if r.reqDuration, err = registerHistogram(c, service, "request_duration_seconds",
+		"Bucketed histogram of processing time (s) of requests.", c.reqDuration); err != nil {
+		return nil, err
+	}
+	if r.reqSize, err = registerHistogram(c, service, "request_size_bytes",
+		"Bucketed histogram of the HTTP request sizes in bytes.", c.reqSize); err != nil {
+		return nil, err
+	}
+	if r.resSize, err = registerHistogram(c, service, "response_size_bytes",
+		"Bucketed histogram of the HTTP response sizes in bytes.", c.resSize); err != nil {
+		return nil, err
+	}
+	inFlight, err := register(c.registerer,
+		prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   c.namespace,
+				Subsystem:   service,
+				Name:        "requests_in_flight",
+				Help:        "Gauge of requests currently being handled.",
+				ConstLabels: c.constLabels,
 			},
-			reqLabels),
-		"request_duration_seconds").(*prometheus.HistogramVec)
-	reqSize = Register(
-		prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "request_size_bytes",
-				Help:      "Bucketed histogram of the HTTP request sizes in bytes.",
-				Buckets:   buckets.reqSize,
+			c.inFlightLabels()))
+	if err != nil {
+		return nil, err
+	}
+	if r.inFlight, ok = inFlight.(*prometheus.GaugeVec); !ok {
+		return nil, errs.Errorf("metric '%s' is already registered with another type", prometheus.BuildFQName(c.namespace, service, "requests_in_flight"))
+	}
+	errCnt, err := register(c.registerer,
+		prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   c.namespace,
+				Subsystem:   service,
+				Name:        "request_errors_total",
+				Help:        "Counter of requests which failed with an error, by error code.",
+				ConstLabels: c.constLabels,
 			},
-			reqLabels),
-		"request_size_bytes").(*prometheus.HistogramVec)
-	resSize = Register(
+			append(labels[:len(labels):len(labels)], "error_code")))
+	if err != nil {
+		return nil, err
+	}
+	if r.errCnt, ok = errCnt.(*prometheus.CounterVec); !ok {
+		return nil, errs.Errorf("metric '%s' is already registered with another type", prometheus.BuildFQName(c.namespace, service, "request_errors_total"))
+	}
+	log.Info(nil, map[string]interface{}{
+		"namespace": c.namespace,
+		"subsystem": service,
+	}, "metrics registered successfully")
+	return r, nil
+}
+
+func registerHistogram(c *recorderConfig, service, name, help string, buckets buckets) (*prometheus.HistogramVec, error) {
+	h, err := register(c.registerer,
 		prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "response_size_bytes",
-				Help:      "Bucketed histogram of the HTTP response sizes in bytes.",
-				Buckets:   buckets.resSize,
+				Namespace:   c.namespace,
+				Subsystem:   service,
+				Name:        name,
+				Help:        help,
+				Buckets:     buckets,
+				ConstLabels: c.constLabels,
 			},
-			reqLabels),
-		"response_size_bytes").(*prometheus.HistogramVec)
-	log.Info(nil, nil, "metrics registered successfully")
+			c.labels()))
+	if err != nil {
+		return nil, err
+	}
+	result, ok := h.(*prometheus.HistogramVec)
+	if !ok {
+		return nil, errs.Errorf("metric '%s' is already registered with another type", prometheus.BuildFQName(c.namespace, service, name))
+	}
+	return result, nil
 }
 
-// Register registers the given collector
+// Register registers the given collector in the default Prometheus registry, or returns
+// the equivalent collector which was already registered
 func Register(c prometheus.Collector, name string) prometheus.Collector {
-	err := prometheus.Register(c)
+	result, err := register(prometheus.DefaultRegisterer, c)
 	if err != nil {
-		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
-			return are.ExistingCollector
-		}
 		log.Panic(nil, map[string]interface{}{
-			"metric_name": prometheus.BuildFQName(namespace, subsystem, name),
+			"metric_name": name,
 			"err":         err,
 		}, "failed to register the prometheus metric")
 	}
 	log.Debug(nil, map[string]interface{}{
-		"metric_name": prometheus.BuildFQName(namespace, subsystem, name),
+		"metric_name": name,
 	}, "metric registered successfully")
-	return c
+	return result
+}
+
+// register registers the given collector with the given registerer, or returns
+// the equivalent collector which was already registered
+func register(r prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	if err := r.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// validLabels returns true if none of the given label values is empty
+func validLabels(labels []string) bool {
+	for _, l := range labels {
+		if l == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RequestRecorder) reportRequestsTotal(labels []string) {
+	if validLabels(labels) {
+		r.reqCnt.WithLabelValues(labels...).Inc()
+	}
+}
+
+func (r *RequestRecorder) reportRequestDuration(ctx context.Context, labels []string, startTime time.Time) {
+	if validLabels(labels) && !startTime.IsZero() {
+		duration := time.Since(startTime).Seconds()
+		observer := r.reqDuration.WithLabelValues(labels...)
+		if r.config.exemplars {
+			if e := exemplarLabels(ctx); e != nil {
+				// the histograms created by the Prometheus client always implement the ExemplarObserver interface
+				observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration, e)
+				return
+			}
+		}
+		observer.Observe(duration)
+	}
 }
 
-func reportRequestsTotal(method, entity, code string) {
-	if method != "" && entity != "" && code != "" {
-		reqCnt.WithLabelValues(method, entity, code).Inc()
+func (r *RequestRecorder) reportResponseSize(labels []string, size int) {
+	if validLabels(labels) && size > 0 {
+		r.resSize.WithLabelValues(labels...).Observe(float64(size))
 	}
 }
 
-func reportRequestDuration(method, entity, code string, startTime time.Time) {
-	if method != "" && entity != "" && code != "" && !startTime.IsZero() {
-		reqDuration.WithLabelValues(method, entity, code).Observe(time.Since(startTime).Seconds())
+func (r *RequestRecorder) reportError(labels []string, errorCode string) {
+	if validLabels(labels) && errorCode != "" {
+		r.errCnt.WithLabelValues(append(labels[:len(labels):len(labels)], errorCode)...).Inc()
 	}
 }
 
-func reportResponseSize(method, entity, code string, size int) {
-	if method != "" && entity != "" && code != "" && size > 0 {
-		resSize.WithLabelValues(method, entity, code).Observe(float64(size))
+// trackInFlight increments the gauge of the requests in flight with the given labels,
+// and returns the function to call to decrement it once the request was handled
+func (r *RequestRecorder) trackInFlight(labels []string) func() {
+	if !validLabels(labels) {
+		return func() {}
 	}
+	g := r.inFlight.WithLabelValues(labels...)
+	g.Inc()
+	return g.Dec
 }
 
-func reportRequestSize(method, entity, code string, size int64) {
-	if method != "" && entity != "" && code != "" && size > 0 {
-		reqSize.WithLabelValues(method, entity, code).Observe(float64(size))
+func (r *RequestRecorder) reportRequestSize(labels []string, size int64) {
+	if validLabels(labels) && size > 0 {
+		r.reqSize.WithLabelValues(labels...).Observe(float64(size))
 	}
 }
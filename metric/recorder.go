@@ -3,10 +3,13 @@ package metric
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fabric8-services/fabric8-common/httpsupport/responsewriter"
 	"github.com/fabric8-services/fabric8-common/log"
 
 	"github.com/goadesign/goa"
@@ -14,54 +17,167 @@ import (
 
 // Recorder record prometheus metrics related to http request and response.
 // the `service` arg is the name of the service, so that metrics can be distinguished
-// as subsystems in Prometheus
-func Recorder(service string, options ...BucketOption) goa.Middleware {
-	registerMetrics(service, options...)
+// as subsystems in Prometheus (see NewRequestRecorder and RequestRecorder.Middleware).
+// The business metrics (see NewCounter, NewTimer and NewGaugeFunc) are registered in the same
+// subsystem, with the same registerer, namespace and constant labels.
+func Recorder(service string, options ...RecorderOption) goa.Middleware {
+	r := mustNewRequestRecorder(service, options...)
+	setDefaults(service, r.config)
+	return r.Middleware()
+}
+
+// Handler returns a handler which records the prometheus metrics related to the requests handled by the
+// given plain `net/http` handler (see NewRequestRecorder and RequestRecorder.Handler). The metrics of the
+// handler and of the Recorder of the same service are shared, so both must be created with the same
+// label scheme (i.e., with or without WithRouteLabels, and with the same constant labels).
+func Handler(service, action string, h http.Handler, options ...RecorderOption) http.Handler {
+	return mustNewRequestRecorder(service, options...).Handler(action, h)
+}
 
+func mustNewRequestRecorder(service string, options ...RecorderOption) *RequestRecorder {
+	r, err := NewRequestRecorder(service, options...)
+	if err != nil {
+		log.Panic(nil, map[string]interface{}{
+			"subsystem": service,
+			"err":       err,
+		}, "failed to register the prometheus metrics")
+	}
+	return r
+}
+
+// Middleware returns a goa middleware which records the metrics of each request: the number of requests in flight
+// while the request is handled, then the requests total, duration and sizes. The requests which failed with an error
+// are also counted by error code, i.e., the `ErrorCode` of the goa response (set by the error handler, which should
+// be mounted after this middleware) or else "unknown". If the handler panics, then the request is recorded with
+// a 500 status and a "panic" error code before the panic is propagated.
+func (r *RequestRecorder) Middleware() goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 			startTime := time.Now()
+			labels := r.config.labelsVal(ctx, 0)
+			done := r.trackInFlight(labels[:len(labels)-1])
+			defer done()
+			defer func() {
+				if p := recover(); p != nil {
+					r.record(ctx, r.config.labelsVal(ctx, http.StatusInternalServerError), req, 0, startTime, "panic")
+					panic(p)
+				}
+			}()
 			err := h(ctx, rw, req)
 
 			// record metrics
-			method, entity, code := labelsVal(ctx)
-			recordReqsTotal(method, entity, code)
-			recordReqSize(method, entity, code, req)
-			recordResSize(method, entity, code, goa.ContextResponse(ctx))
-			recordReqDuration(method, entity, code, startTime)
+			resp := goa.ContextResponse(ctx)
+			status := responsewriter.GoaStatus(ctx, err)
+			var errorCode string
+			if resp.ErrorCode != "" {
+				errorCode = resp.ErrorCode
+			} else if err != nil {
+				errorCode = "unknown"
+			}
+			r.record(ctx, r.config.labelsVal(ctx, status), req, resp.Length, startTime, errorCode)
 
 			return err
 		}
 	}
 }
 
-func recordReqsTotal(method, entity, code string) {
-	reportRequestsTotal(method, entity, code)
+// Handler returns a handler which records the metrics of the requests handled by the given plain `net/http`
+// handler (see Middleware). If the recorder was configured with WithRouteLabels, then the `action` label is
+// the given name of the handler (e.g. "status.show") and the `route` is obtained with the function set with
+// WithRouteFunc. Otherwise, the `entity` label is the given name of the handler.
+func (r *RequestRecorder) Handler(action string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		startTime := time.Now()
+		labels := func(status int) []string {
+			if r.config.routeLabels {
+				return []string{methodVal(req.Method), action, r.config.routeFunc(req), r.config.codeVal(status)}
+			}
+			return []string{methodVal(req.Method), action, r.config.codeVal(status)}
+		}
+		inFlightLabels := labels(0)
+		done := r.trackInFlight(inFlightLabels[:len(inFlightLabels)-1])
+		defer done()
+		recorder := responsewriter.NewRecorder(rw)
+		defer func() {
+			if p := recover(); p != nil {
+				r.record(req.Context(), labels(http.StatusInternalServerError), req, recorder.Length(), startTime, "panic")
+				panic(p)
+			}
+		}()
+		h.ServeHTTP(recorder, req)
+
+		// record metrics
+		r.record(req.Context(), labels(recorder.Status()), req, recorder.Length(), startTime, "")
+	})
+}
+
+// record records the metrics of a request which was handled in the given context, and of the error if the given
+// error code is not empty
+func (r *RequestRecorder) record(ctx context.Context, labels []string, req *http.Request, resSize int, startTime time.Time, errorCode string) {
+	r.reportRequestsTotal(labels)
+	r.reportRequestSize(labels, computeApproximateRequestSize(req))
+	r.reportResponseSize(labels, resSize)
+	r.reportRequestDuration(ctx, labels, startTime)
+	r.reportError(labels, errorCode)
 }
 
-func recordReqSize(method, entity, code string, req *http.Request) {
-	size := computeApproximateRequestSize(req)
-	reportRequestSize(method, entity, code, size)
+// labelsVal returns the values of the labels of the metrics for the request in the given context
+// and the given response status
+func (c *recorderConfig) labelsVal(ctx context.Context, status int) []string {
+	req := goa.ContextRequest(ctx)
+	if !c.routeLabels {
+		return []string{methodVal(req.Method), entityVal(goa.ContextController(ctx)), c.codeVal(status)}
+	}
+	return []string{
+		methodVal(req.Method),
+		actionVal(goa.ContextController(ctx), goa.ContextAction(ctx)),
+		routeVal(req),
+		c.codeVal(status),
+	}
 }
 
-func recordResSize(method, entity, code string, res *goa.ResponseData) {
-	size := res.Length
-	reportResponseSize(method, entity, code, size)
+// codeVal returns the exact status code or the class of the status code, depending on the config
+func (c *recorderConfig) codeVal(status int) string {
+	if c.statusCode {
+		return strconv.Itoa(status)
+	}
+	return codeVal(status)
 }
 
-func recordReqDuration(method, entity, code string, startTime time.Time) {
-	reportRequestDuration(method, entity, code, startTime)
+// ctrl=SpaceController, action=show -> action=space.show
+func actionVal(ctrl, action string) string {
+	return strings.ToLower(strings.TrimSuffix(ctrl, "Controller")) + "." + action
 }
 
-func labelsVal(ctx context.Context) (method, entity, code string) {
-	method = methodVal(goa.ContextRequest(ctx).Method)
-	ctrl := goa.ContextController(ctx)
-	entity = entityVal(ctrl)
-	status := goa.ContextResponse(ctx).Status
-	code = codeVal(status)
-	log.Debug(ctx, nil, "method=%s, ctrl=%s, entity=%s, status=%d, code=%s",
-		method, ctrl, entity, status, code)
-	return method, entity, code
+// routeVal returns the route pattern of the request, in which the segments of the path matching
+// a path parameter are replaced with the name of the parameter
+// (e.g. "/api/spaces/8ee6cd2b-3b5b-4f8a-a0a5-1bfa8ed6a5c2" -> "/api/spaces/:spaceID").
+// Since goa merges the path and query parameters, the parameters whose value is in the query are ignored.
+func routeVal(req *goa.RequestData) string {
+	if req.URL == nil {
+		return ""
+	}
+	query := req.URL.Query()
+	names := make([]string, 0, len(req.Params))
+	for name, values := range req.Params {
+		if len(values) == 1 && values[0] != "" && query.Get(name) != values[0] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if s, err := url.PathUnescape(segment); err == nil {
+			segment = s
+		}
+		for _, name := range names {
+			if req.Params.Get(name) == segment {
+				segments[i] = ":" + name
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
 func methodVal(method string) string {
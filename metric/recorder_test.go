@@ -2,6 +2,7 @@ package metric
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/goadesign/goa"
+	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,28 +32,28 @@ var (
 )
 
 func TestReqsTotalMetric(t *testing.T) {
-	Recorder("TestReqsTotal")
+	r := newTestRecorder(t, "TestReqsTotal")
 
 	// for dummy entity, POST=3 and GET=1
-	recordReqsTotal(post, dummy, "2xx")
-	recordReqsTotal(get, dummy, "2xx")
-	recordReqsTotal(post, dummy, "2xx")
-	recordReqsTotal(post, dummy, "4xx")
+	recordRequest(r, post, dummy, "2xx", &http.Request{}, 0, time.Now())
+	recordRequest(r, get, dummy, "2xx", &http.Request{}, 0, time.Now())
+	recordRequest(r, post, dummy, "2xx", &http.Request{}, 0, time.Now())
+	recordRequest(r, post, dummy, "4xx", &http.Request{}, 0, time.Now())
 
 	// for test entity, POST=1 and GET=1
-	recordReqsTotal(post, test, "2xx")
-	recordReqsTotal(get, test, "2xx")
+	recordRequest(r, post, test, "2xx", &http.Request{}, 0, time.Now())
+	recordRequest(r, get, test, "2xx", &http.Request{}, 0, time.Now())
 
 	// validate
-	checkCounter(t, post, dummy, "2xx", 2)
-	checkCounter(t, get, dummy, "2xx", 1)
-	checkCounter(t, post, dummy, "4xx", 1)
-	checkCounter(t, post, test, "2xx", 1)
-	checkCounter(t, get, test, "2xx", 1)
+	checkCounter(t, r, post, dummy, "2xx", 2)
+	checkCounter(t, r, get, dummy, "2xx", 1)
+	checkCounter(t, r, post, dummy, "4xx", 1)
+	checkCounter(t, r, post, test, "2xx", 1)
+	checkCounter(t, r, get, test, "2xx", 1)
 }
 
 func TestReqDurationMetric(t *testing.T) {
-	Recorder("TestReqDuration")
+	r := newTestRecorder(t, "TestReqDuration")
 
 	reqTimes := []time.Duration{51, 101, 201, 401, 801, 1601, 3201, 6401}
 	expectedBound := []float64{0.05, 0.1, 0.2, 0.4, 0.8, 1.6, 3.2, 6.4}
@@ -60,22 +62,22 @@ func TestReqDurationMetric(t *testing.T) {
 	// add post method
 	for _, reqTime := range reqTimes {
 		startTime := time.Now().Add(time.Millisecond * -reqTime)
-		recordReqDuration(post, dummy, "2xx", startTime)
+		recordRequest(r, post, dummy, "2xx", &http.Request{}, 0, startTime)
 	}
 
 	// add get method to make sure that this should be filtered out
-	recordReqDuration(get, dummy, "2xx", time.Now())
+	recordRequest(r, get, dummy, "2xx", &http.Request{}, 0, time.Now())
 
 	// validate
-	reqMetric, _ := reqDuration.GetMetricWithLabelValues(post, dummy, "2xx")
+	reqMetric, _ := r.reqDuration.GetMetricWithLabelValues(post, dummy, "2xx")
 	m := &dto.Metric{}
-	err := reqMetric.Write(m)
+	err := reqMetric.(prometheus.Histogram).Write(m)
 	require.NoError(t, err)
 	checkHistogram(t, m, uint64(len(reqTimes)), expectedBound, expectedCnt)
 }
 
 func TestResSizeMetric(t *testing.T) {
-	Recorder("TestResSize")
+	r := newTestRecorder(t, "TestResSize")
 
 	resSizes := []int{1001, 5001, 10001, 20001, 30001, 40001, 50001}
 	expectedBound := []float64{1000, 5000, 10000, 20000, 30000, 40000, 50000}
@@ -83,24 +85,22 @@ func TestResSizeMetric(t *testing.T) {
 
 	// add get method for dummy entity
 	for _, size := range resSizes {
-		res := &goa.ResponseData{Length: size}
-		recordResSize(get, dummy, "2xx", res)
+		recordRequest(r, get, dummy, "2xx", &http.Request{}, size, time.Now())
 	}
 
 	// add get method for test entity to make sure that this should be filtered out
-	res := &goa.ResponseData{Length: 1000}
-	recordResSize(get, test, "2xx", res)
+	recordRequest(r, get, test, "2xx", &http.Request{}, 1000, time.Now())
 
 	// validate
-	reqMetric, _ := resSize.GetMetricWithLabelValues(get, dummy, "2xx")
+	reqMetric, _ := r.resSize.GetMetricWithLabelValues(get, dummy, "2xx")
 	m := &dto.Metric{}
-	err := reqMetric.Write(m)
+	err := reqMetric.(prometheus.Histogram).Write(m)
 	require.NoError(t, err)
 	checkHistogram(t, m, uint64(len(resSizes)), expectedBound, expectedCnt)
 }
 
 func TestReqSizeMetric(t *testing.T) {
-	Recorder("TestReqSize")
+	r := newTestRecorder(t, "TestReqSize")
 
 	reqSizes := []int64{1001, 5001, 10001, 20001, 30001, 40001, 50001}
 	expectedBound := []float64{1000, 5000, 10000, 20000, 30000, 40000, 50000}
@@ -109,17 +109,17 @@ func TestReqSizeMetric(t *testing.T) {
 	// add post method for dummy entity
 	for _, size := range reqSizes {
 		req := &http.Request{ContentLength: size}
-		recordReqSize(post, dummy, "2xx", req)
+		recordRequest(r, post, dummy, "2xx", req, 0, time.Now())
 	}
 
 	// add post method for test entity to make sure that this should be filtered out
 	req := &http.Request{ContentLength: 1000}
-	recordReqSize(post, test, "2xx", req)
+	recordRequest(r, post, test, "2xx", req, 0, time.Now())
 
 	// validate
-	reqMetric, _ := reqSize.GetMetricWithLabelValues(post, dummy, "2xx")
+	reqMetric, _ := r.reqSize.GetMetricWithLabelValues(post, dummy, "2xx")
 	m := &dto.Metric{}
-	err := reqMetric.Write(m)
+	err := reqMetric.(prometheus.Histogram).Write(m)
 	require.NoError(t, err)
 	checkHistogram(t, m, uint64(len(reqSizes)), expectedBound, expectedCnt)
 }
@@ -129,18 +129,18 @@ func TestCustomBucket1(t *testing.T) {
 	expectedBound := []float64{10, 20}
 	expectedCnt := []uint64{1, 2}
 
-	Recorder("TestCustom1", WithRequestSizeBucket(expectedBound))
+	r := newTestRecorder(t, "TestCustom1", WithRequestSizeBucket(expectedBound))
 
 	// add post method for dummy entity
 	for _, size := range reqSizes {
 		req := &http.Request{ContentLength: size}
-		recordReqSize(post, dummy, "2xx", req)
+		recordRequest(r, post, dummy, "2xx", req, 0, time.Now())
 	}
 
 	// validate
-	reqMetric, _ := reqSize.GetMetricWithLabelValues(post, dummy, "2xx")
+	reqMetric, _ := r.reqSize.GetMetricWithLabelValues(post, dummy, "2xx")
 	m := &dto.Metric{}
-	err := reqMetric.Write(m)
+	err := reqMetric.(prometheus.Histogram).Write(m)
 	require.NoError(t, err)
 	checkHistogram(t, m, uint64(len(reqSizes)), expectedBound, expectedCnt)
 }
@@ -150,40 +150,251 @@ func TestCustomBucket2(t *testing.T) {
 	expectedBound := []float64{10, 20}
 	expectedCnt := []uint64{1, 2}
 
-	Recorder("TestCustom2", WithResponseSizeBucket(expectedBound))
+	r := newTestRecorder(t, "TestCustom2", WithResponseSizeBucket(expectedBound))
 
 	// add get method for dummy entity
 	for _, size := range resSizes {
-		res := &goa.ResponseData{Length: size}
-		recordResSize(get, dummy, "2xx", res)
+		recordRequest(r, get, dummy, "2xx", &http.Request{}, size, time.Now())
 	}
 
 	// add get method for test entity to make sure that this should be filtered out
-	res := &goa.ResponseData{Length: 1000}
-	recordResSize(get, test, "2xx", res)
+	recordRequest(r, get, test, "2xx", &http.Request{}, 1000, time.Now())
 
 	// validate
-	reqMetric, _ := resSize.GetMetricWithLabelValues(get, dummy, "2xx")
+	reqMetric, _ := r.resSize.GetMetricWithLabelValues(get, dummy, "2xx")
 	m := &dto.Metric{}
-	err := reqMetric.Write(m)
+	err := reqMetric.(prometheus.Histogram).Write(m)
 	require.NoError(t, err)
 	checkHistogram(t, m, uint64(len(resSizes)), expectedBound, expectedCnt)
 }
 func TestLabelsVal(t *testing.T) {
 	svc := goa.New("metric")
 	ctrl := svc.NewController(dummyCtrl)
+	c := newRecorderConfig()
 
 	ctx := createCtx(ctrl, getMethod, 200)
-	method, entity, code := labelsVal(ctx)
-	assert.Equal(t, "get", method)
-	assert.Equal(t, "dummy", entity)
-	assert.Equal(t, "2xx", code)
+	assert.Equal(t, []string{"get", "dummy", "2xx"}, c.labelsVal(ctx, goa.ContextResponse(ctx).Status))
 
 	ctx = createCtx(ctrl, postMethod, 201)
-	method, entity, code = labelsVal(ctx)
-	assert.Equal(t, "post", method)
-	assert.Equal(t, "dummy", entity)
-	assert.Equal(t, "2xx", code)
+	assert.Equal(t, []string{"post", "dummy", "2xx"}, c.labelsVal(ctx, goa.ContextResponse(ctx).Status))
+}
+
+func TestRouteLabelsVal(t *testing.T) {
+	svc := goa.New("metric")
+	ctrl := svc.NewController("SpaceController")
+	c := newRecorderConfig(WithRouteLabels(), WithStatusCodeLabel())
+
+	req := &http.Request{
+		Method: getMethod,
+		URL:    &url.URL{Path: "/api/spaces/8ee6cd2b/iterations/1", RawQuery: "page=1"},
+	}
+	rw := httptest.NewRecorder()
+	ctx := goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, url.Values{
+		"spaceID":     []string{"8ee6cd2b"},
+		"iterationID": []string{"1"},
+		"page":        []string{"1"},
+	})
+	goa.ContextResponse(ctx).Status = 201
+
+	assert.Equal(t, []string{"get", "space.show", "/api/spaces/:spaceID/iterations/:iterationID", "201"}, c.labelsVal(ctx, goa.ContextResponse(ctx).Status))
+}
+
+func TestEntityLabelsWithStatusCode(t *testing.T) {
+	svc := goa.New("metric")
+	ctrl := svc.NewController(dummyCtrl)
+	c := newRecorderConfig(WithStatusCodeLabel())
+
+	ctx := createCtx(ctrl, postMethod, 201)
+	assert.Equal(t, []string{"post", "dummy", "201"}, c.labelsVal(ctx, goa.ContextResponse(ctx).Status))
+}
+
+func TestRecorderWithRouteLabels(t *testing.T) {
+	svc := goa.New("metric")
+	svc.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	ctrl := svc.NewController("Foo")
+	registry := prometheus.NewRegistry()
+	m := Recorder("TestRouteLabels", WithRouteLabels(), WithRegisterer(registry))
+	h := m(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		return svc.Send(ctx, 404, "not found")
+	})
+
+	req := &http.Request{Method: getMethod, URL: &url.URL{Path: "/api/foo/bar"}}
+	rw := httptest.NewRecorder()
+	ctx := goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, url.Values{"id": []string{"bar"}})
+	require.NoError(t, h(ctx, rw, req))
+
+	// the request is counted even if the controller name does not end with "Controller"
+	assert.Equal(t, float64(1), gatherCounter(t, registry, "TestRouteLabels_requests_total", map[string]string{
+		"method": "get", "action": "foo.show", "route": "/api/foo/:id", "code": "4xx",
+	}))
+}
+
+func TestHandler(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	h := Handler("TestHandler", "status.show", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}), WithRouteLabels(), WithStatusCodeLabel(), WithRegisterer(registry))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(getMethod, "/api/status", nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, float64(2), gatherCounter(t, registry, "TestHandler_requests_total", map[string]string{
+		"method": "get", "action": "status.show", "route": "/api/status", "code": "503",
+	}))
+}
+
+func TestHandlerAndRecorder(t *testing.T) {
+	t.Run("same label scheme", func(t *testing.T) {
+		// given a goa middleware and a plain handler in the same subsystem
+		registry := prometheus.NewRegistry()
+		options := []RecorderOption{WithRouteLabels(), WithRegisterer(registry)}
+		m := Recorder("TestHandlerAndRecorder", options...)
+		h := Handler("TestHandlerAndRecorder", "status.show", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		}), options...)
+		svc := goa.New("TestHandlerAndRecorder")
+		svc.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		ctrl := svc.NewController("StatusController")
+		goaHandler := m(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return svc.Send(ctx, http.StatusOK, "ok")
+		})
+		// when
+		req := httptest.NewRequest(getMethod, "/api/status", nil)
+		rw := httptest.NewRecorder()
+		require.NoError(t, goaHandler(goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, nil), rw, req))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(getMethod, "/api/status", nil))
+		// then both requests are recorded in the same metrics
+		assert.Equal(t, float64(2), gatherCounter(t, registry, "TestHandlerAndRecorder_requests_total", map[string]string{
+			"method": "get", "action": "status.show", "route": "/api/status", "code": "2xx",
+		}))
+	})
+
+	t.Run("different label schemes", func(t *testing.T) {
+		// given
+		registry := prometheus.NewRegistry()
+		_, err := NewRequestRecorder("TestHandlerAndRecorder", WithRegisterer(registry))
+		require.NoError(t, err)
+		// when
+		_, err = NewRequestRecorder("TestHandlerAndRecorder", WithRouteLabels(), WithRegisterer(registry))
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "same label scheme")
+	})
+}
+
+func TestMultipleRecorders(t *testing.T) {
+	// given
+	registry1 := prometheus.NewRegistry()
+	registry2 := prometheus.NewRegistry()
+	r1, err := NewRequestRecorder("TestMultiple", WithRegisterer(registry1), WithNamespace("f8"),
+		WithConstLabels(prometheus.Labels{"cluster": "c1"}))
+	require.NoError(t, err)
+	r2, err := NewRequestRecorder("TestMultiple", WithRegisterer(registry2))
+	require.NoError(t, err)
+	// the same recorder can be created again in the same registry
+	r3, err := NewRequestRecorder("TestMultiple", WithRegisterer(registry2))
+	require.NoError(t, err)
+	// when
+	recordRequest(r1, get, dummy, "2xx", &http.Request{}, 0, time.Now())
+	recordRequest(r2, get, dummy, "2xx", &http.Request{}, 0, time.Now())
+	recordRequest(r3, get, dummy, "2xx", &http.Request{}, 0, time.Now())
+	// then
+	assert.Equal(t, float64(1), gatherCounter(t, registry1, "f8_TestMultiple_requests_total", map[string]string{
+		"method": get, "entity": dummy, "code": "2xx", "cluster": "c1",
+	}))
+	assert.Equal(t, float64(2), gatherCounter(t, registry2, "TestMultiple_requests_total", map[string]string{
+		"method": get, "entity": dummy, "code": "2xx",
+	}))
+}
+
+func TestRecorderWithConflictingLabels(t *testing.T) {
+	// given
+	registry := prometheus.NewRegistry()
+	_, err := NewRequestRecorder("TestConflict", WithRegisterer(registry))
+	require.NoError(t, err)
+	// when
+	_, err = NewRequestRecorder("TestConflict", WithRegisterer(registry), WithRouteLabels())
+	// then
+	assert.Error(t, err)
+}
+
+func TestRecorderInFlightAndErrors(t *testing.T) {
+	svc := goa.New("metric")
+	svc.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	ctrl := svc.NewController(dummyCtrl)
+	registry := prometheus.NewRegistry()
+	r, err := NewRequestRecorder("TestInFlight", WithRegisterer(registry), WithStatusCodeLabel())
+	require.NoError(t, err)
+
+	t.Run("in flight", func(t *testing.T) {
+		h := r.Middleware()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			assert.Equal(t, float64(1), gatherGauge(t, registry, "TestInFlight_requests_in_flight", map[string]string{
+				"method": get, "entity": dummy,
+			}))
+			return svc.Send(ctx, 200, "ok")
+		})
+		ctx := newRequestCtx(ctrl, getMethod)
+		require.NoError(t, h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request))
+		assert.Equal(t, float64(0), gatherGauge(t, registry, "TestInFlight_requests_in_flight", map[string]string{
+			"method": get, "entity": dummy,
+		}))
+	})
+
+	t.Run("error code", func(t *testing.T) {
+		h := r.Middleware()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			goa.ContextResponse(ctx).ErrorCode = "not_found"
+			return svc.Send(ctx, 404, "not found")
+		})
+		ctx := newRequestCtx(ctrl, postMethod)
+		require.NoError(t, h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request))
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestInFlight_request_errors_total", map[string]string{
+			"method": post, "entity": dummy, "code": "404", "error_code": "not_found",
+		}))
+	})
+
+	t.Run("unhandled error", func(t *testing.T) {
+		h := r.Middleware()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return errors.New("failure")
+		})
+		ctx := newRequestCtx(ctrl, "PUT")
+		require.Error(t, h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request))
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestInFlight_request_errors_total", map[string]string{
+			"method": "put", "entity": dummy, "code": "500", "error_code": "unknown",
+		}))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		h := r.Middleware()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			panic("boom")
+		})
+		ctx := newRequestCtx(ctrl, "DELETE")
+		assert.PanicsWithValue(t, "boom", func() {
+			h(ctx, goa.ContextResponse(ctx), goa.ContextRequest(ctx).Request)
+		})
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestInFlight_requests_total", map[string]string{
+			"method": "delete", "entity": dummy, "code": "500",
+		}))
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestInFlight_request_errors_total", map[string]string{
+			"method": "delete", "entity": dummy, "code": "500", "error_code": "panic",
+		}))
+		assert.Equal(t, float64(0), gatherGauge(t, registry, "TestInFlight_requests_in_flight", map[string]string{
+			"method": "delete", "entity": dummy,
+		}))
+	})
+
+	t.Run("net/http panic", func(t *testing.T) {
+		h := r.Handler("status", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}))
+		assert.Panics(t, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(getMethod, "/api/status", nil))
+		})
+		assert.Equal(t, float64(1), gatherCounter(t, registry, "TestInFlight_request_errors_total", map[string]string{
+			"method": get, "entity": "status", "code": "500", "error_code": "panic",
+		}))
+	})
 }
 
 func TestMethodVal(t *testing.T) {
@@ -261,6 +472,23 @@ func TestComputeApproximateRequestSize(t *testing.T) {
 	}
 }
 
+// recordRequest records the metrics of a request with the given labels, request, response size and start time
+func recordRequest(r *RequestRecorder, method, entity, code string, req *http.Request, resSize int, startTime time.Time) {
+	r.record(context.Background(), []string{method, entity, code}, req, resSize, startTime, "")
+}
+
+func newTestRecorder(t *testing.T, service string, options ...RecorderOption) *RequestRecorder {
+	r, err := NewRequestRecorder(service, append(options, WithRegisterer(prometheus.NewRegistry()))...)
+	require.NoError(t, err)
+	return r
+}
+
+// newRequestCtx returns the context of a request which was not handled yet
+func newRequestCtx(ctrl *goa.Controller, reqMethod string) context.Context {
+	req := &http.Request{Host: "localhost", Method: reqMethod}
+	return goa.NewContext(ctrl.Context, httptest.NewRecorder(), req, url.Values{})
+}
+
 func createCtx(ctrl *goa.Controller, reqMethod string, resCode int) context.Context {
 	req := &http.Request{Host: "localhost", Method: reqMethod}
 	rw := httptest.NewRecorder()
@@ -270,8 +498,8 @@ func createCtx(ctrl *goa.Controller, reqMethod string, resCode int) context.Cont
 	return ctx
 }
 
-func checkCounter(t *testing.T, method, entity, code string, expected int64) {
-	reqMetric, _ := reqCnt.GetMetricWithLabelValues(method, entity, code)
+func checkCounter(t *testing.T, r *RequestRecorder, method, entity, code string, expected int64) {
+	reqMetric, _ := r.reqCnt.GetMetricWithLabelValues(method, entity, code)
 	m := &dto.Metric{}
 	err := reqMetric.Write(m)
 	require.NoError(t, err)
@@ -281,6 +509,53 @@ func checkCounter(t *testing.T, method, entity, code string, expected int64) {
 	}
 }
 
+// gatherGauge returns the value of the gauge with the given name and labels in the given registry
+func gatherGauge(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string) float64 {
+	m := gatherMetric(t, g, name, labels)
+	if m == nil {
+		return 0
+	}
+	return m.GetGauge().GetValue()
+}
+
+// gatherCounter returns the value of the counter with the given name and labels in the given registry
+func gatherCounter(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string) float64 {
+	m := gatherMetric(t, g, name, labels)
+	if m == nil {
+		return 0
+	}
+	return m.GetCounter().GetValue()
+}
+
+func gatherMetric(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string) *dto.Metric {
+	m := findMetric(t, g, name, labels)
+	if m == nil {
+		t.Errorf("metric %s%v not found", name, labels)
+	}
+	return m
+}
+
+// findMetric returns the metric with the given name and labels in the given registry, or nil if there is none
+func findMetric(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string) *dto.Metric {
+	families, err := g.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			actual := map[string]string{}
+			for _, l := range m.GetLabel() {
+				actual[l.GetName()] = l.GetValue()
+			}
+			if assert.ObjectsAreEqual(labels, actual) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
 func checkHistogram(t *testing.T, m *dto.Metric, expectedCount uint64, expectedBound []float64, expectedCnt []uint64) {
 	if expectedCount != m.Histogram.GetSampleCount() {
 		t.Errorf("Histogram count was incorrect, want: %d, got: %d",
@@ -68,7 +68,7 @@ func Migrate(db *sql.DB, catalog string, migrateData MigrateData) error {
 					"next_version": nextVersion,
 					"migrations":   m,
 					"err":          err,
-				}, "error while rolling back transaction: ", err)
+				}, "error while rolling back transaction: %v", err)
 				return errs.Errorf("error while rolling back transaction: %s", err)
 			}
 			return oldErr
@@ -112,7 +112,7 @@ func migrateToNextVersion(tx *sql.Tx, nextVersion *int64, m migrations, catalog
 	// Once obtained, the lock is held for the remainder of the current transaction.
 	// (There is no UNLOCK TABLE command; locks are always released at transaction end.)
 	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", advisoryLockID); err != nil {
-		return errs.Wrapf(err, "failed to acquire lock: %s", advisoryLockID)
+		return errs.Wrapf(err, "failed to acquire lock: %d", advisoryLockID)
 	}
 
 	// Determine current version and adjust the outmost loop
@@ -193,13 +193,24 @@ func executeSQLFile(Asset func(string) ([]byte, error), filename string, args ..
 	}
 }
 
+// CurrentVersion returns the current version of the schema of the given database,
+// i.e., the highest version from the version table or -1 if no migration was applied yet.
+func CurrentVersion(db *sql.DB, catalog string) (int64, error) {
+	return getCurrentVersion(db, catalog)
+}
+
+// rowQuerier the common interface of sql.DB and sql.Tx to query a single row
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // getCurrentVersion returns the highest version from the version
 // table or -1 if that table does not exist.
 //
 // Returning -1 simplifies the logic of the migration process because
 // the next version is always the current version + 1 which results
 // in -1 + 1 = 0 which is exactly what we want as the first version.
-func getCurrentVersion(db *sql.Tx, catalog string) (int64, error) {
+func getCurrentVersion(db rowQuerier, catalog string) (int64, error) {
 	query := `SELECT EXISTS(
 				SELECT 1 FROM information_schema.tables
 				WHERE table_catalog=$1
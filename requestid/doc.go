@@ -0,0 +1,4 @@
+// Package requestid provides the middlewares which assign an ID to each incoming request,
+// and the context accessors which let the log entries, the Sentry reports, the proxied requests
+// and the outgoing HTTP requests share this ID.
+package requestid
@@ -0,0 +1,114 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/middleware"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Header the name of the request and response header containing the request ID
+	Header = "X-Request-ID"
+	// MaxLength the maximum length of an incoming request ID
+	MaxLength = 128
+)
+
+type contextKey int
+
+const (
+	// requestIDKey the context key used to store the request ID
+	requestIDKey contextKey = iota + 1
+)
+
+// validID the pattern of the incoming request IDs which are accepted. It allows UUIDs and the IDs
+// generated by goa's RequestID middleware, but rejects the values which could be used to inject
+// content in the logs (e.g. line breaks)
+var validID = regexp.MustCompile(`^[a-zA-Z0-9\-_.:+/=]+$`)
+
+// New generates a new request ID
+func New() string {
+	return uuid.NewV4().String()
+}
+
+// IsValid returns true if the given (incoming) request ID is acceptable
+func IsValid(id string) bool {
+	return len(id) > 0 && len(id) <= MaxLength && validID.MatchString(id)
+}
+
+// NewContext returns a copy of the given context in which the given request ID is stored. The ID is also
+// stored as the request ID of the goa clients, so it is sent in the requests made with such clients.
+func NewContext(ctx context.Context, id string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, requestIDKey, id)
+	return client.SetContextRequestID(ctx, id)
+}
+
+// FromContext returns the request ID stored in the given context by the middlewares of this package
+// or by NewContext, or else by goa's RequestID middleware, or else by goa's client.SetContextRequestID.
+// Returns an empty string if the context contains no request ID.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(requestIDKey).(string); ok && id != "" {
+		return id
+	}
+	if id := middleware.ContextRequestID(ctx); id != "" {
+		return id
+	}
+	return client.ContextRequestID(ctx)
+}
+
+// fromRequest returns the request ID from the given request header if it is valid,
+// or else from the given context, or else a new request ID
+func fromRequest(ctx context.Context, req *http.Request) string {
+	if id := req.Header.Get(Header); IsValid(id) {
+		return id
+	}
+	if id := FromContext(ctx); id != "" {
+		return id
+	}
+	return New()
+}
+
+// Middleware returns a goa middleware which stores the request ID in the context (see NewContext)
+// and sets it in the `X-Request-ID` response header. The request ID is taken from the `X-Request-ID`
+// request header if it is valid, otherwise a new ID is generated.
+// This middleware replaces goa's `middleware.RequestID`.
+func Middleware() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			id := fromRequest(ctx, req)
+			rw.Header().Set(Header, id)
+			return h(NewContext(ctx, id), rw, req)
+		}
+	}
+}
+
+// Handler returns an HTTP handler which stores the request ID in the context of the request
+// before calling the given handler, the same way as Middleware does for goa services.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		id := fromRequest(req.Context(), req)
+		rw.Header().Set(Header, id)
+		h.ServeHTTP(rw, req.WithContext(NewContext(req.Context(), id)))
+	})
+}
+
+// SetHeader sets the request ID stored in the given context (if any) in the `X-Request-ID`
+// header of the given outgoing request, unless it was already set.
+func SetHeader(ctx context.Context, req *http.Request) {
+	if req.Header.Get(Header) != "" {
+		return
+	}
+	if id := FromContext(ctx); id != "" {
+		req.Header.Set(Header, id)
+	}
+}
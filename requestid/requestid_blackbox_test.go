@@ -0,0 +1,178 @@
+package requestid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/requestid"
+	"github.com/fabric8-services/fabric8-common/resource"
+
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testCases := map[string]bool{
+		"":                                       false,
+		"8ee6cd2b-3b5b-4f8a-a0a5-1bfa8ed6a5c2":   true,
+		"hostname/Ab3dE6fgH1-42":                 true,
+		"foo\nbar":                               false,
+		"foo bar":                                false,
+		"<script>":                               false,
+		strings.Repeat("a", requestid.MaxLength): true,
+		strings.Repeat("a", requestid.MaxLength+1): false,
+	}
+	for id, expected := range testCases {
+		assert.Equal(t, expected, requestid.IsValid(id), "'%s'", id)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("nil context", func(t *testing.T) {
+		assert.Empty(t, requestid.FromContext(nil))
+	})
+
+	t.Run("empty context", func(t *testing.T) {
+		assert.Empty(t, requestid.FromContext(context.Background()))
+	})
+
+	t.Run("stored with NewContext", func(t *testing.T) {
+		// when
+		ctx := requestid.NewContext(context.Background(), "foo")
+		// then
+		assert.Equal(t, "foo", requestid.FromContext(ctx))
+		// also available to the goa clients
+		assert.Equal(t, "foo", client.ContextRequestID(ctx))
+	})
+
+	t.Run("stored by a goa client", func(t *testing.T) {
+		// when
+		ctx := client.SetContextRequestID(context.Background(), "bar")
+		// then
+		assert.Equal(t, "bar", requestid.FromContext(ctx))
+	})
+
+	t.Run("stored by the goa middleware", func(t *testing.T) {
+		// given
+		var ctx context.Context
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.RequestIDHeader, "baz")
+		h := middleware.RequestID()(func(c context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ctx = c
+			return nil
+		})
+		// when
+		err := h(context.Background(), httptest.NewRecorder(), req)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "baz", requestid.FromContext(ctx))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	testCases := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"valid incoming request ID", "8ee6cd2b-3b5b-4f8a-a0a5-1bfa8ed6a5c2", true},
+		{"invalid incoming request ID", "foo\r\nX-Injected: bar", false},
+		{"missing incoming request ID", "", false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("goa", func(t *testing.T) {
+				// given
+				var reqID string
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set(requestid.Header, tc.incoming)
+				rw := httptest.NewRecorder()
+				h := requestid.Middleware()(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					reqID = requestid.FromContext(ctx)
+					return nil
+				})
+				// when
+				err := h(context.Background(), rw, req)
+				// then
+				require.NoError(t, err)
+				assertRequestID(t, tc.incoming, tc.keep, reqID, rw)
+			})
+
+			t.Run("http", func(t *testing.T) {
+				// given
+				var reqID string
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set(requestid.Header, tc.incoming)
+				rw := httptest.NewRecorder()
+				h := requestid.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					reqID = requestid.FromContext(req.Context())
+				}))
+				// when
+				h.ServeHTTP(rw, req)
+				// then
+				assertRequestID(t, tc.incoming, tc.keep, reqID, rw)
+			})
+		})
+	}
+}
+
+func assertRequestID(t *testing.T, incoming string, keep bool, reqID string, rw *httptest.ResponseRecorder) {
+	require.NotEmpty(t, reqID)
+	if keep {
+		assert.Equal(t, incoming, reqID)
+	} else {
+		assert.NotEqual(t, incoming, reqID)
+		assert.True(t, requestid.IsValid(reqID))
+	}
+	assert.Equal(t, reqID, rw.Header().Get(requestid.Header))
+}
+
+func TestSetHeader(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	ctx := requestid.NewContext(context.Background(), "foo")
+
+	t.Run("header not set", func(t *testing.T) {
+		// given
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		// when
+		requestid.SetHeader(ctx, req)
+		// then
+		assert.Equal(t, "foo", req.Header.Get(requestid.Header))
+	})
+
+	t.Run("header already set", func(t *testing.T) {
+		// given
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "bar")
+		// when
+		requestid.SetHeader(ctx, req)
+		// then
+		assert.Equal(t, "bar", req.Header.Get(requestid.Header))
+	})
+
+	t.Run("no request ID", func(t *testing.T) {
+		// given
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		// when
+		requestid.SetHeader(context.Background(), req)
+		// then
+		assert.Empty(t, req.Header.Get(requestid.Header))
+	})
+}
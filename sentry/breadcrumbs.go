@@ -0,0 +1,96 @@
+package sentry
+
+import (
+	"sync"
+
+	"github.com/fabric8-services/fabric8-common/log"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+const (
+	// maxBreadcrumbs the maximum number of breadcrumbs attached to a report, i.e., kept for each request
+	maxBreadcrumbs = 30
+	// maxBreadcrumbRequests the maximum number of requests whose breadcrumbs are kept
+	maxBreadcrumbRequests = 1000
+)
+
+// breadcrumbs the latest breadcrumbs of the latest requests, by request ID
+type breadcrumbs struct {
+	mu        sync.Mutex
+	byRequest map[string][]*sentrygo.Breadcrumb
+	// requests the IDs of the requests with breadcrumbs, from the oldest to the latest
+	requests []string
+}
+
+func newBreadcrumbs() *breadcrumbs {
+	return &breadcrumbs{
+		byRequest: map[string][]*sentrygo.Breadcrumb{},
+	}
+}
+
+// add adds the given breadcrumb to the breadcrumbs of the request with the given ID (which may be empty),
+// and discards the oldest breadcrumbs or requests beyond the limits
+func (b *breadcrumbs) add(reqID string, crumb *sentrygo.Breadcrumb) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	crumbs, found := b.byRequest[reqID]
+	if !found {
+		b.requests = append(b.requests, reqID)
+		if len(b.requests) > maxBreadcrumbRequests {
+			delete(b.byRequest, b.requests[0])
+			b.requests = b.requests[1:]
+		}
+	}
+	if len(crumbs) >= maxBreadcrumbs {
+		crumbs = crumbs[1:]
+	}
+	b.byRequest[reqID] = append(crumbs, crumb)
+}
+
+// get returns the breadcrumbs of the request with the given ID (which may be empty)
+func (b *breadcrumbs) get(reqID string) []*sentrygo.Breadcrumb {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	crumbs := b.byRequest[reqID]
+	// copies, which can be modified before being sent (e.g. redacted)
+	result := make([]*sentrygo.Breadcrumb, len(crumbs))
+	for i, crumb := range crumbs {
+		c := *crumb
+		result[i] = &c
+	}
+	return result
+}
+
+// record records the given log entry as a breadcrumb of its request (see log.AddHook)
+func (b *breadcrumbs) record(entry log.Entry) {
+	reqID, _ := entry.Fields["req_id"].(string)
+	data := make(map[string]interface{}, len(entry.Fields))
+	for k, v := range entry.Fields {
+		if k != "req_id" {
+			data[k] = v
+		}
+	}
+	b.add(reqID, &sentrygo.Breadcrumb{
+		Category:  "log",
+		Message:   entry.Message,
+		Data:      data,
+		Level:     breadcrumbLevel(entry.Level),
+		Timestamp: entry.Time,
+	})
+}
+
+func breadcrumbLevel(level log.Level) sentrygo.Level {
+	switch level {
+	case log.DebugLevel:
+		return sentrygo.LevelDebug
+	case log.InfoLevel:
+		return sentrygo.LevelInfo
+	case log.WarnLevel:
+		return sentrygo.LevelWarning
+	case log.ErrorLevel:
+		return sentrygo.LevelError
+	default:
+		return sentrygo.LevelFatal
+	}
+}
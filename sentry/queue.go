@@ -0,0 +1,169 @@
+package sentry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabric8-services/fabric8-common/log"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+const (
+	// defaultQueueSize the default maximum number of reports waiting to be sent (see WithQueueSize)
+	defaultQueueSize = 100
+	// defaultWorkers the default number of goroutines sending the reports (see WithWorkers)
+	defaultWorkers = 2
+)
+
+// report an error to send to Sentry, along with the scope (user, tags and breadcrumbs)
+// extracted from the context of the caller
+type report struct {
+	ctx   context.Context
+	err   error
+	scope *sentrygo.Scope
+	// stacktrace the stack trace of the caller, if the error has none
+	stacktrace *sentrygo.Stacktrace
+}
+
+// queue the bounded queue of the reports waiting to be sent by the workers
+type queue struct {
+	// mu guards the reports channel against the sends after it was closed
+	mu      sync.RWMutex
+	closed  bool
+	reports chan report
+	// pending the reports which were queued but not sent yet
+	pending sync.WaitGroup
+	dropped uint64
+}
+
+func newQueue(size, workers int, send func(report)) *queue {
+	q := &queue{
+		reports: make(chan report, size),
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for r := range q.reports {
+				send(r)
+				q.pending.Done()
+			}
+		}()
+	}
+	return q
+}
+
+// push queues the given report without blocking, or drops it if the queue is full or closed.
+// Returns false if the report was dropped.
+func (q *queue) push(r report) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+	q.pending.Add(1)
+	select {
+	case q.reports <- r:
+		return true
+	default:
+		q.pending.Done()
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+}
+
+// wait waits until all the queued reports were sent, or until the timeout expires.
+// Returns false if the timeout expired.
+func (q *queue) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// close stops accepting new reports. The workers exit once the queued reports were sent.
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.reports)
+}
+
+// WithQueueSize sets the maximum number of reports waiting to be sent, beyond which
+// the reported errors are dropped instead of blocking the callers of CaptureError
+// (100 by default). InitializeSentryClient returns an error if the size is lower than 1.
+func WithQueueSize(size int) func(*client) {
+	return func(c *client) {
+		c.queueSize = size
+	}
+}
+
+// WithWorkers sets the number of goroutines sending the queued reports to Sentry (2 by default).
+// InitializeSentryClient returns an error if the number of workers is lower than 1.
+func WithWorkers(workers int) func(*client) {
+	return func(c *client) {
+		c.workers = workers
+	}
+}
+
+// Flush waits until the queued reports were sent to Sentry, or until the timeout expires.
+// Returns false if the timeout expired before all the reports were sent.
+func (c *client) Flush(timeout time.Duration) bool {
+	if c == nil {
+		return true
+	}
+	start := time.Now()
+	if !c.queue.wait(timeout) {
+		return false
+	}
+	return c.c.Flush(timeout - time.Since(start))
+}
+
+// Dropped returns the number of reports which were dropped because the queue was full
+// or because the client was closed
+func (c *client) Dropped() uint64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&c.queue.dropped)
+}
+
+// enqueue queues the given report, or logs it if the report was dropped
+func (c *client) enqueue(r report) {
+	if c.queue.push(r) {
+		return
+	}
+	log.Warn(r.ctx, map[string]interface{}{
+		"err":             r.err,
+		"dropped_reports": c.Dropped(),
+	}, "dropped the report of the error to Sentry")
+}
+
+// send sends the given report to Sentry
+func (c *client) send(r report) {
+	c.c.CaptureException(r.err, &sentrygo.EventHint{Context: r.ctx, OriginalException: r.err, Data: r.stacktrace}, r.scope)
+}
+
+// setCallerStacktrace replaces the stack trace of the worker which sent the report with the stack
+// trace of the caller of CaptureError, when the reported error has no stack trace (see send)
+func setCallerStacktrace(event *sentrygo.Event, hint *sentrygo.EventHint) {
+	if hint == nil || len(event.Exception) == 0 {
+		return
+	}
+	if st, ok := hint.Data.(*sentrygo.Stacktrace); ok && st != nil {
+		// the outermost error is the last one
+		event.Exception[len(event.Exception)-1].Stacktrace = st
+	}
+}
@@ -2,23 +2,57 @@ package sentry
 
 import (
 	"context"
+	"math/rand"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/fabric8-services/fabric8-common/log"
 
-	"github.com/getsentry/raven-go"
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
 )
 
+// flushTimeout the maximum duration to wait for the pending reports to be sent when the client is closed
+const flushTimeout = 2 * time.Second
+
+// User the user who sent the request in which an error occurred (see WithUser)
+type User = sentrygo.User
+
+// Event the report of an error sent to Sentry (see WithBeforeSend)
+type Event = sentrygo.Event
+
 // client encapsulates client to Sentry service
-// also has mutex which controls access to the client
 type client struct {
-	c        *raven.Client
-	sendErr  chan func()
-	userInfo func(ctx context.Context) (*raven.User, error)
+	c           *sentrygo.Client
+	userInfo    func(ctx context.Context) (*User, error)
+	release     string
+	environment string
+	// breadcrumbs the breadcrumbs recorded from the log entries, if enabled with WithLogBreadcrumbs
+	breadcrumbs *breadcrumbs
+	// transport the transport of the reports (the default HTTP transport of the Sentry SDK if nil)
+	transport sentrygo.Transport
+	queueSize int
+	workers   int
+	// beforeSend the function called with each event before it is redacted and sent, if set with WithBeforeSend
+	beforeSend func(ctx context.Context, err error, event *Event) *Event
+	// sampleRate the fraction of the reported errors which are sent
+	sampleRate float64
+	// ignoredErrors the functions matching the errors which are not reported
+	ignoredErrors []func(error) (bool, error)
+	// redactor the redactor of the reports (the redactor of the logs if nil)
+	redactor *log.Redactor
+	// queue the reports waiting to be sent, so that CaptureError does not block its callers
+	queue *queue
+	// close closes the client (see InitializeSentryClient)
+	close func()
 }
 
 var (
 	sentryClient *client
+	// initMu guards the replacement of the client
+	initMu sync.Mutex
 )
 
 // Sentry returns client declared inside package
@@ -28,7 +62,10 @@ func Sentry() *client {
 
 // InitializeSentryClient initializes sentry client. This function returns
 // function that can be used to close the sentry client and error.
-// sentryDSN param is optional. If null then DSN set via SENTRY_DSN env var will be used
+// sentryDSN param is optional. If null then DSN set via SENTRY_DSN env var will be used.
+// If a client was already initialized, then it is replaced and closed: its queued reports are
+// sent (within a short timeout), then its workers and its log hook (see WithLogBreadcrumbs) are
+// released. Closing a client more than once is harmless.
 func InitializeSentryClient(sentryDSN *string, options ...func(*client)) (func(), error) {
 	var dsn string
 	if sentryDSN != nil {
@@ -36,28 +73,59 @@ func InitializeSentryClient(sentryDSN *string, options ...func(*client)) (func()
 	} else {
 		dsn = os.Getenv("SENTRY_DSN")
 	}
-	c, err := raven.New(dsn)
+	c := &client{
+		queueSize:  defaultQueueSize,
+		workers:    defaultWorkers,
+		sampleRate: 1,
+	}
+	// set all options passed by user
+	for _, opt := range options {
+		opt(c)
+	}
+	if c.queueSize < 1 {
+		return nil, errs.Errorf("invalid size of the queue of the reports: %d (must be at least 1)", c.queueSize)
+	}
+	if c.workers < 1 {
+		return nil, errs.Errorf("invalid number of workers sending the reports: %d (must be at least 1)", c.workers)
+	}
+	sc, err := sentrygo.NewClient(sentrygo.ClientOptions{
+		Dsn:         dsn,
+		Release:     c.release,
+		Environment: c.environment,
+		Transport:   c.transport,
+		BeforeSend:  c.beforeSendEvent,
+	})
 	if err != nil {
 		return nil, err
 	}
-	sentryClient = &client{
-		c:       c,
-		sendErr: make(chan func()),
+	c.c = sc
+	c.queue = newQueue(c.queueSize, c.workers, c.send)
+	removeHook := func() {}
+	if c.breadcrumbs != nil {
+		removeHook = log.AddHook(c.breadcrumbs.record)
 	}
-	// set all options passed by user
-	for _, opt := range options {
-		opt(sentryClient)
+	var once sync.Once
+	c.close = func() {
+		once.Do(func() {
+			removeHook()
+			// the reports captured after the client was closed are dropped
+			c.queue.close()
+			c.Flush(flushTimeout)
+		})
 	}
 
-	// wait on errors to be sent on channel of client object
-	go sentryClient.loop()
-	return func() {
-		close(sentryClient.sendErr)
-	}, nil
+	initMu.Lock()
+	defer initMu.Unlock()
+	previous := sentryClient
+	sentryClient = c
+	if previous != nil && previous.close != nil {
+		previous.close()
+	}
+	return c.close, nil
 }
 
 // WithUser helps to set user context
-func WithUser(userInfo func(ctx context.Context) (*raven.User, error)) func(*client) {
+func WithUser(userInfo func(ctx context.Context) (*User, error)) func(*client) {
 	return func(c *client) {
 		c.userInfo = userInfo
 	}
@@ -67,7 +135,7 @@ func WithUser(userInfo func(ctx context.Context) (*raven.User, error)) func(*cli
 // code while initializing sentry client using function InitializeSentryClient
 func WithRelease(release string) func(*client) {
 	return func(c *client) {
-		c.c.SetRelease(release)
+		c.release = release
 	}
 }
 
@@ -76,44 +144,181 @@ func WithRelease(release string) func(*client) {
 // InitializeSentryClient
 func WithEnvironment(env string) func(*client) {
 	return func(c *client) {
-		c.c.SetEnvironment(env)
+		c.environment = env
+	}
+}
+
+// WithLogBreadcrumbs records the log entries as breadcrumbs, which are attached to the reports of the errors
+// which occurred in the same request (or to the reports of the errors without request ID for the entries
+// without request ID). The entries are recorded with a log hook until the client is closed, whatever the
+// log backend (see log.AddHook).
+func WithLogBreadcrumbs() func(*client) {
+	return func(c *client) {
+		c.breadcrumbs = newBreadcrumbs()
+	}
+}
+
+// WithBeforeSend sets a function called with the context and the error of each report before it is sent, which
+// may modify the event, or return nil to discard it. The sensitive data of the event is masked afterwards.
+func WithBeforeSend(beforeSend func(ctx context.Context, err error, event *Event) *Event) func(*client) {
+	return func(c *client) {
+		c.beforeSend = beforeSend
 	}
 }
 
-// waits on functions to be sent on channel
-// which are then executed
-func (c *client) loop() {
-	for op := range c.sendErr {
-		op()
+// WithSampleRate sends only the given fraction (between 0 and 1) of the reported errors, picked at random
+// (all of them by default)
+func WithSampleRate(rate float64) func(*client) {
+	return func(c *client) {
+		c.sampleRate = rate
+	}
+}
+
+// WithIgnoredErrors discards the errors matched by any of the given functions, such as the `Is...Error`
+// functions of the errors package. For example, to ignore the errors resulting in 4xx responses:
+//
+//	sentry.WithIgnoredErrors(errors.IsBadParameterError, errors.IsNotFoundError)
+func WithIgnoredErrors(matchers ...func(error) (bool, error)) func(*client) {
+	return func(c *client) {
+		c.ignoredErrors = append(c.ignoredErrors, matchers...)
+	}
+}
+
+// WithRedactor masks the sensitive data of the reports (such as emails and tokens) with the given redactor,
+// instead of the redactor of the logs (see log.CurrentRedactor)
+func WithRedactor(r *log.Redactor) func(*client) {
+	return func(c *client) {
+		c.redactor = r
 	}
 }
 
 // CaptureError sends error 'err' to Sentry, meanwhile also sets user
-// information by extracting user information from the context provided
+// information by extracting user information from the context provided,
+// along with the request ID, controller and action tags. The stack trace
+// of the report is the one of the innermost error wrapped with github.com/pkg/errors
+// (if any), otherwise the stack trace of the caller. The report is sent
+// asynchronously, and dropped if the queue is full (see WithQueueSize and Flush).
+// The ignored errors and the errors left out by sampling are not reported (see
+// WithIgnoredErrors and WithSampleRate).
 func (c *client) CaptureError(ctx context.Context, err error) {
 	// if method called during test which has uninitialized client
 	if c == nil {
 		return
 	}
-	// Extract user information. Ignoring error here but then before using the
-	// object user make sure to check if it wasn't nil.
-	var user *raven.User
-	if c.userInfo != nil {
-		user, _ = c.userInfo(ctx)
+	if c.isIgnored(err) || !c.isSampled() {
+		return
+	}
+	scope := sentrygo.NewScope()
+	var reqID string
+	if ctx != nil {
+		// Extract user information. Ignoring error here but then before using the
+		// object user make sure to check if it wasn't nil.
+		if c.userInfo != nil {
+			if user, _ := c.userInfo(ctx); user != nil {
+				scope.SetUser(*user)
+			}
+		}
+		reqID = log.ExtractRequestID(ctx)
+		if reqID != "" {
+			scope.SetTag("request_id", reqID)
+		}
+		if ctrl := goa.ContextController(ctx); ctrl != "" {
+			scope.SetTag("controller", ctrl)
+		}
+		if action := goa.ContextAction(ctx); action != "" {
+			scope.SetTag("action", action)
+		}
+	}
+	if c.breadcrumbs != nil {
+		for _, b := range c.breadcrumbs.get(reqID) {
+			scope.AddBreadcrumb(b, maxBreadcrumbs)
+		}
 	}
-	reqID := log.ExtractRequestID(ctx)
+	r := report{ctx: ctx, err: err, scope: scope}
+	if err == nil || sentrygo.ExtractStacktrace(err) == nil {
+		r.stacktrace = sentrygo.NewStacktrace()
+	}
+	c.enqueue(r)
+}
 
-	c.sendErr <- func() {
-		if user != nil {
-			c.c.SetUserContext(user)
+// isIgnored returns true if the given error is matched by one of the ignored errors
+func (c *client) isIgnored(err error) bool {
+	for _, match := range c.ignoredErrors {
+		if ignored, _ := match(err); ignored {
+			return true
 		}
+	}
+	return false
+}
 
-		additionalContext := make(map[string]string)
-		if reqID != "" {
-			additionalContext["req_ID"] = reqID
+// isSampled returns true if the current error should be reported, according to the sample rate
+func (c *client) isSampled() bool {
+	return c.sampleRate >= 1 || rand.Float64() < c.sampleRate
+}
+
+// beforeSendEvent keeps the stack trace of the caller of CaptureError, calls the function set
+// with WithBeforeSend, and masks the sensitive data of the event the same way as in the logs
+func (c *client) beforeSendEvent(event *Event, hint *sentrygo.EventHint) *Event {
+	setCallerStacktrace(event, hint)
+	if c.beforeSend != nil {
+		var ctx context.Context
+		var err error
+		if hint != nil {
+			ctx, err = hint.Context, hint.OriginalException
 		}
+		if event = c.beforeSend(ctx, err, event); event == nil {
+			return nil
+		}
+	}
+	r := c.redactor
+	if r == nil {
+		r = log.CurrentRedactor()
+	}
+	redactEvent(r, event)
+	return event
+}
 
-		c.c.CaptureError(err, additionalContext)
-		c.c.ClearContext()
+// redactEvent masks the sensitive data in the message, tags, extra data, contexts, user,
+// breadcrumbs, exceptions and HTTP request of the given event
+func redactEvent(r *log.Redactor, event *sentrygo.Event) {
+	if r == nil || event == nil {
+		return
+	}
+	event.Message = r.RedactString(event.Message)
+	for k, v := range event.Tags {
+		event.Tags[k] = r.RedactString(v)
+	}
+	if event.Extra != nil {
+		event.Extra = r.RedactFields(event.Extra)
+	}
+	if event.Contexts != nil {
+		event.Contexts = r.RedactFields(event.Contexts)
+	}
+	// the ID of the user is kept, so the reports can still be related to the user
+	event.User.Email = r.RedactString(event.User.Email)
+	event.User.Username = r.RedactString(event.User.Username)
+	for _, b := range event.Breadcrumbs {
+		b.Message = r.RedactString(b.Message)
+		if b.Data != nil {
+			b.Data = r.RedactFields(b.Data)
+		}
+	}
+	for i := range event.Exception {
+		event.Exception[i].Value = r.RedactString(event.Exception[i].Value)
+	}
+	if req := event.Request; req != nil {
+		req.URL = r.RedactString(req.URL)
+		req.QueryString = r.RedactString(req.QueryString)
+		req.Data = r.RedactString(req.Data)
+		if req.Cookies != "" {
+			req.Cookies = log.DefaultMask
+		}
+		headers := make(map[string]interface{}, len(req.Headers))
+		for k, v := range req.Headers {
+			headers[k] = v
+		}
+		for k, v := range r.RedactFields(headers) {
+			req.Headers[k], _ = v.(string)
+		}
 	}
 }
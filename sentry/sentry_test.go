@@ -3,17 +3,27 @@ package sentry
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fabric8-services/fabric8-common/auth"
+	ferrors "github.com/fabric8-services/fabric8-common/errors"
+	"github.com/fabric8-services/fabric8-common/log"
+	"github.com/fabric8-services/fabric8-common/log/logtest"
+	"github.com/fabric8-services/fabric8-common/requestid"
 	"github.com/fabric8-services/fabric8-common/resource"
 	testauth "github.com/fabric8-services/fabric8-common/test/auth"
 
 	"errors"
 	"github.com/dgrijalva/jwt-go"
-	"github.com/getsentry/raven-go"
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/goadesign/goa"
 	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	errs "github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,7 +51,7 @@ func withValidToken(t *testing.T, identityID string, identityUsername string) (c
 func TestExtractUserInfo(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
 	close, err := InitializeSentryClient(nil,
-		WithUser(func(ctx context.Context) (*raven.User, error) {
+		WithUser(func(ctx context.Context) (*User, error) {
 			m, err := auth.ReadManagerFromContext(ctx)
 			if err != nil {
 				return nil, err
@@ -55,7 +65,7 @@ func TestExtractUserInfo(t *testing.T) {
 				return nil, err
 			}
 
-			return &raven.User{
+			return &User{
 				Username: t.Username,
 				Email:    t.Email,
 				ID:       t.Subject,
@@ -98,7 +108,7 @@ func TestExtractUserInfo(t *testing.T) {
 		// then
 		require.NoError(t, err)
 		require.NotNil(t, userInfo)
-		assert.Equal(t, raven.User{
+		assert.Equal(t, User{
 			Username: username,
 			ID:       userID.String(),
 			Email:    username + "@email.com",
@@ -146,26 +156,403 @@ func TestCaptureError(t *testing.T) {
 
 func TestDSN(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
-	// Set default DSN via env var
-	defaultProject := uuid.NewV4()
-	dsn := fmt.Sprintf("https://%s:%s@test.io/%s", uuid.NewV4(), uuid.NewV4(), defaultProject)
+	// Set default DSN via env var (the project IDs are numeric)
+	defaultKey, defaultSecret, defaultProject := uuid.NewV4(), uuid.NewV4(), rand.Intn(100000)
+	dsn := fmt.Sprintf("https://%s:%s@test.io/%d", defaultKey, defaultSecret, defaultProject)
 	old := os.Getenv("SENTRY_DSN")
 	os.Setenv("SENTRY_DSN", dsn)
 	defer os.Setenv("SENTRY_DSN", old)
 
 	// Init DSN explicitly
-	project := uuid.NewV4()
-	dsn = fmt.Sprintf("https://%s:%s@test.io/%s", uuid.NewV4(), uuid.NewV4(), project)
+	project := rand.Intn(100000)
+	dsn = fmt.Sprintf("https://%s:%s@test.io/%d", uuid.NewV4(), uuid.NewV4(), project)
 	_, err := InitializeSentryClient(&dsn)
 	require.NoError(t, err)
 
 	// The env var is not used. Explicitly set DSN is used instead.
-	assert.Equal(t, fmt.Sprintf("https://test.io/api/%s/store/", project), Sentry().c.URL())
+	assert.Equal(t, dsn, Sentry().c.Options().Dsn)
 
 	// Init the default DSN
 	_, err = InitializeSentryClient(nil)
 	require.NoError(t, err)
 
 	// The DSN from the env var is used
-	assert.Equal(t, fmt.Sprintf("https://test.io/api/%s/store/", defaultProject), Sentry().c.URL())
+	assert.Equal(t, fmt.Sprintf("https://%s:%s@test.io/%d", defaultKey, defaultSecret, defaultProject), Sentry().c.Options().Dsn)
+}
+
+func TestRedactEvent(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	event := &sentrygo.Event{
+		Message: "failed to call https://auth?client_secret=foo",
+		Tags:    map[string]string{"user": "user@acme.com"},
+		Extra:   map[string]interface{}{"password": "bar", "name": "baz"},
+		Contexts: map[string]interface{}{
+			"oauth": map[string]interface{}{"access_token": "abcdef"},
+		},
+		User: User{ID: "user-1", Username: "user@acme.com", Email: "user@acme.com"},
+		Breadcrumbs: []*sentrygo.Breadcrumb{
+			{Message: "calling https://auth?client_secret=foo", Data: map[string]interface{}{"access_token": "abcdef"}},
+		},
+		Exception: []sentrygo.Exception{
+			{Value: "invalid token: Bearer abcdef"},
+		},
+		Request: &sentrygo.Request{
+			URL:     "https://auth/token?refresh_token=secret",
+			Cookies: "session=foo",
+			Headers: map[string]string{"Authorization": "Bearer abcdef", "Accept": "application/json"},
+		},
+	}
+	// when
+	redactEvent(log.NewRedactor(), event)
+	// then
+	assert.Equal(t, "failed to call https://auth?client_secret=*****", event.Message)
+	assert.Equal(t, "*****", event.Tags["user"])
+	assert.Equal(t, map[string]interface{}{"password": "*****", "name": "baz"}, event.Extra)
+	assert.Equal(t, map[string]interface{}{"oauth": map[string]interface{}{"access_token": "*****"}}, event.Contexts)
+	assert.Equal(t, User{ID: "user-1", Username: "*****", Email: "*****"}, event.User)
+	assert.Equal(t, "calling https://auth?client_secret=*****", event.Breadcrumbs[0].Message)
+	assert.Equal(t, map[string]interface{}{"access_token": "*****"}, event.Breadcrumbs[0].Data)
+	assert.Equal(t, "invalid token: *****", event.Exception[0].Value)
+	assert.Equal(t, "https://auth/token?refresh_token=*****", event.Request.URL)
+	assert.Equal(t, "*****", event.Request.Cookies)
+	assert.Equal(t, map[string]string{"Authorization": "*****", "Accept": "application/json"}, event.Request.Headers)
+}
+
+func TestCaptureErrorReport(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	transport := &testTransport{}
+	close, err := InitializeSentryClient(nil,
+		WithRelease("123"),
+		WithEnvironment("test"),
+		WithLogBreadcrumbs(),
+		WithUser(func(ctx context.Context) (*User, error) {
+			return &User{ID: "user-1"}, nil
+		}),
+		func(c *client) {
+			c.transport = transport
+		})
+	require.NoError(t, err)
+	defer close()
+	svc := goa.New("test")
+	ctrl := svc.NewController("SpaceController")
+	ctx := goa.WithAction(goa.NewContext(ctrl.Context, httptest.NewRecorder(), httptest.NewRequest("GET", "/api/spaces", nil), nil), "show")
+	ctx = requestid.NewContext(ctx, "req-1")
+	log.Warn(ctx, nil, "something odd happened")
+	log.Warn(requestid.NewContext(context.Background(), "req-2"), nil, "unrelated")
+	// when
+	Sentry().CaptureError(ctx, errs.Wrap(errs.New("not found"), "failed to load the space"))
+	// then
+	require.True(t, Sentry().Flush(time.Second))
+	events := transport.Events()
+	require.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, "123", e.Release)
+	assert.Equal(t, "test", e.Environment)
+	assert.Equal(t, "user-1", e.User.ID)
+	assert.Equal(t, "req-1", e.Tags["request_id"])
+	assert.Equal(t, "SpaceController", e.Tags["controller"])
+	assert.Equal(t, "show", e.Tags["action"])
+	require.Len(t, e.Breadcrumbs, 1)
+	assert.Equal(t, "something odd happened", e.Breadcrumbs[0].Message)
+	assert.Equal(t, sentrygo.LevelWarning, e.Breadcrumbs[0].Level)
+	require.NotEmpty(t, e.Exception)
+	// the stack trace of the innermost error is reported
+	innermost := e.Exception[0]
+	assert.Equal(t, "not found", innermost.Value)
+	require.NotNil(t, innermost.Stacktrace)
+	frames := innermost.Stacktrace.Frames
+	require.NotEmpty(t, frames)
+	assert.Equal(t, "TestCaptureErrorReport", frames[len(frames)-1].Function)
+
+	t.Run("error without stack trace", func(t *testing.T) {
+		// when
+		Sentry().CaptureError(ctx, errors.New("not found"))
+		// then the stack trace of the caller is reported, not the one of the worker
+		require.True(t, Sentry().Flush(time.Second))
+		events := transport.Events()
+		require.Len(t, events, 2)
+		require.Len(t, events[1].Exception, 1)
+		require.NotNil(t, events[1].Exception[0].Stacktrace)
+		functions := []string{}
+		for _, f := range events[1].Exception[0].Stacktrace.Frames {
+			functions = append(functions, f.Function)
+		}
+		assert.Contains(t, functions, "(*client).CaptureError")
+		assert.NotContains(t, functions, "(*client).send")
+	})
+}
+
+func TestLogBreadcrumbs(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given a level changed at runtime before the client is initialized
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	defer log.ResetLevels()
+	require.NoError(t, log.SetLevel(log.DebugLevel, 0))
+	transport := &testTransport{}
+	close, err := InitializeSentryClient(nil,
+		WithLogBreadcrumbs(),
+		func(c *client) {
+			c.transport = transport
+		})
+	require.NoError(t, err)
+	defer close()
+	ctx := requestid.NewContext(context.Background(), "req-1")
+
+	t.Run("level changes are kept", func(t *testing.T) {
+		// when
+		log.Debug(ctx, nil, "debug")
+		Sentry().CaptureError(ctx, errors.New("failed"))
+		// then
+		assert.Equal(t, log.DebugLevel, log.GetLevel())
+		require.NoError(t, log.SetPackageLevel("jsonapi", log.InfoLevel, 0))
+		require.True(t, Sentry().Flush(time.Second))
+		events := transport.Events()
+		require.Len(t, events, 1)
+		require.Len(t, events[0].Breadcrumbs, 1)
+		assert.Equal(t, "debug", events[0].Breadcrumbs[0].Message)
+		assert.Len(t, backend.Entries(), 1)
+	})
+
+	t.Run("backend set after the client is kept on close", func(t *testing.T) {
+		// given
+		other := logtest.NewBackend(log.WarnLevel)
+		log.SetBackend(other)
+		// when
+		close()
+		// then
+		assert.Equal(t, other, log.CurrentBackend())
+	})
+}
+
+func TestCaptureErrorQueue(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given a single worker blocked on sending the first report, and a queue of one report
+	transport := &testTransport{
+		received: make(chan struct{}, 10),
+		release:  make(chan struct{}),
+	}
+	close, err := InitializeSentryClient(nil,
+		WithQueueSize(1),
+		WithWorkers(1),
+		func(c *client) {
+			c.transport = transport
+		})
+	require.NoError(t, err)
+	defer func() {
+		// closing twice is harmless
+		close()
+	}()
+	Sentry().CaptureError(context.Background(), errors.New("first"))
+	<-transport.received
+
+	t.Run("drop when full", func(t *testing.T) {
+		// when
+		Sentry().CaptureError(context.Background(), errors.New("second"))
+		Sentry().CaptureError(context.Background(), errors.New("third"))
+		// then
+		assert.Equal(t, uint64(1), Sentry().Dropped())
+		assert.False(t, Sentry().Flush(10*time.Millisecond))
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		// when
+		transport.release <- struct{}{}
+		transport.release <- struct{}{}
+		// then
+		require.True(t, Sentry().Flush(time.Second))
+		events := transport.Events()
+		require.Len(t, events, 2)
+		assert.Equal(t, "first", events[0].Exception[0].Value)
+		assert.Equal(t, "second", events[1].Exception[0].Value)
+	})
+
+	t.Run("capture after close", func(t *testing.T) {
+		// given
+		close()
+		// when
+		Sentry().CaptureError(context.Background(), errors.New("fourth"))
+		// then
+		assert.Equal(t, uint64(2), Sentry().Dropped())
+		assert.True(t, Sentry().Flush(time.Second))
+		assert.Len(t, transport.Events(), 2)
+	})
+}
+
+func TestReinitialize(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	backend := logtest.NewBackend(log.InfoLevel)
+	defer log.SetBackend(log.CurrentBackend())
+	log.SetBackend(backend)
+	transport := &testTransport{}
+	closeFirst, err := InitializeSentryClient(nil,
+		WithLogBreadcrumbs(),
+		func(c *client) {
+			c.transport = transport
+		})
+	require.NoError(t, err)
+	first := Sentry()
+	first.CaptureError(context.Background(), errors.New("first"))
+	// when
+	closeSecond, err := InitializeSentryClient(nil,
+		func(c *client) {
+			c.transport = transport
+		})
+	require.NoError(t, err)
+	defer closeSecond()
+	// then the first client was closed after its queued report was sent
+	assert.NotEqual(t, first, Sentry())
+	require.Len(t, transport.Events(), 1)
+	assert.Equal(t, "first", transport.Events()[0].Exception[0].Value)
+	first.CaptureError(context.Background(), errors.New("after close"))
+	assert.Equal(t, uint64(1), first.Dropped())
+	// and its log hook was removed
+	log.Info(nil, nil, "not recorded")
+	assert.Empty(t, first.breadcrumbs.get(""))
+	// and closing it again is harmless
+	closeFirst()
+}
+
+func TestInvalidQueueOptions(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	previous := Sentry()
+	defer func() {
+		sentryClient = previous
+	}()
+	for name, option := range map[string]func(*client){
+		"empty queue":      WithQueueSize(0),
+		"negative size":    WithQueueSize(-1),
+		"no worker":        WithWorkers(0),
+		"negative workers": WithWorkers(-2),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			close, err := InitializeSentryClient(nil, option)
+			// then
+			require.Error(t, err)
+			assert.Nil(t, close)
+			assert.Equal(t, previous, Sentry())
+		})
+	}
+}
+
+func TestCaptureErrorFiltering(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("ignored errors and before send", func(t *testing.T) {
+		// given
+		transport := &testTransport{}
+		close, err := InitializeSentryClient(nil,
+			WithIgnoredErrors(ferrors.IsBadParameterError, ferrors.IsNotFoundError),
+			WithBeforeSend(func(ctx context.Context, err error, event *Event) *Event {
+				if err.Error() == "discarded" {
+					return nil
+				}
+				event.Tags["hook"] = "called"
+				event.Extra = map[string]interface{}{"contact": "user@acme.com"}
+				return event
+			}),
+			func(c *client) {
+				c.transport = transport
+			})
+		require.NoError(t, err)
+		defer close()
+		// when
+		Sentry().CaptureError(context.Background(), errs.Wrap(ferrors.NewBadParameterError("title", ""), "failed"))
+		Sentry().CaptureError(context.Background(), ferrors.NewNotFoundError("space", "1"))
+		Sentry().CaptureError(context.Background(), errors.New("discarded"))
+		Sentry().CaptureError(context.Background(), ferrors.NewInternalErrorFromString("failed"))
+		// then
+		require.True(t, Sentry().Flush(time.Second))
+		events := transport.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, "failed", events[0].Exception[0].Value)
+		assert.Equal(t, "called", events[0].Tags["hook"])
+		// the data added by the hook is redacted too
+		assert.Equal(t, map[string]interface{}{"contact": "*****"}, events[0].Extra)
+	})
+
+	t.Run("sample rate", func(t *testing.T) {
+		for _, rate := range []float64{0, 1} {
+			t.Run(fmt.Sprintf("%v", rate), func(t *testing.T) {
+				// given
+				transport := &testTransport{}
+				close, err := InitializeSentryClient(nil,
+					WithSampleRate(rate),
+					func(c *client) {
+						c.transport = transport
+					})
+				require.NoError(t, err)
+				defer close()
+				// when
+				for i := 0; i < 10; i++ {
+					Sentry().CaptureError(context.Background(), errors.New("failed"))
+				}
+				// then
+				require.True(t, Sentry().Flush(time.Second))
+				assert.Len(t, transport.Events(), int(rate*10))
+			})
+		}
+	})
+
+	t.Run("custom redactor", func(t *testing.T) {
+		// given
+		transport := &testTransport{}
+		close, err := InitializeSentryClient(nil,
+			WithRedactor(log.NewRedactor(log.WithRedactedKeys("X-Api-Key"))),
+			WithBeforeSend(func(ctx context.Context, err error, event *Event) *Event {
+				event.Extra = map[string]interface{}{"x-api-key": "abcdef"}
+				return event
+			}),
+			func(c *client) {
+				c.transport = transport
+			})
+		require.NoError(t, err)
+		defer close()
+		// when
+		Sentry().CaptureError(context.Background(), errors.New("failed"))
+		// then
+		require.True(t, Sentry().Flush(time.Second))
+		events := transport.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, map[string]interface{}{"x-api-key": "*****"}, events[0].Extra)
+	})
+}
+
+// testTransport a transport which keeps the events in memory
+type testTransport struct {
+	mu     sync.Mutex
+	events []*sentrygo.Event
+	// received if not nil, notified of each event before it is kept
+	received chan struct{}
+	// release if not nil, each event is kept once a value is received from this channel
+	release chan struct{}
+}
+
+func (t *testTransport) Configure(options sentrygo.ClientOptions) {}
+
+func (t *testTransport) SendEvent(event *sentrygo.Event) {
+	if t.received != nil {
+		t.received <- struct{}{}
+	}
+	if t.release != nil {
+		<-t.release
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *testTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func (t *testTransport) Events() []*sentrygo.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.events
 }
@@ -0,0 +1,24 @@
+//go:build tools
+// +build tools
+
+package tools
+
+import (
+	_ "github.com/davecgh/go-spew/spew"
+	_ "github.com/fzipp/gocyclo"
+	_ "github.com/goadesign/goa/cors"
+	_ "github.com/goadesign/goa/design/apidsl"
+	_ "github.com/goadesign/goa/encoding/form"
+	_ "github.com/goadesign/goa/goagen"
+	_ "github.com/goadesign/goa/goagen/codegen"
+	_ "github.com/goadesign/goa/goagen/gen_app"
+	_ "github.com/goadesign/goa/goagen/gen_controller"
+	_ "github.com/goadesign/goa/goagen/gen_swagger"
+	_ "github.com/goadesign/goa/goagen/utils"
+	_ "github.com/goadesign/goa/goatest"
+	_ "github.com/gojuno/minimock/cmd/minimock"
+	_ "github.com/golang/lint/golint"
+	_ "github.com/jteeuwen/go-bindata/go-bindata"
+	_ "github.com/pilu/fresh"
+	_ "github.com/wadey/gocovmerge"
+)
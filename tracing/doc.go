@@ -0,0 +1,5 @@
+// Package tracing provides a minimal distributed tracing support based on the W3C Trace Context
+// specification (https://www.w3.org/TR/trace-context/): spans stored in the request context, goa and HTTP
+// middlewares which start a server span per request, propagation of the `traceparent` header in the outgoing
+// requests, and an Exporter interface to send the ended spans to a tracing backend.
+package tracing
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Exporter the interface to implement to send the ended spans to a tracing backend
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+var (
+	exporterMu sync.RWMutex
+	exporter   Exporter
+)
+
+// SetExporter sets the exporter of the ended spans. Use nil (the default) to disable the export,
+// in which case the trace context is still propagated to the other services and included in the logs.
+func SetExporter(e Exporter) {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	exporter = e
+}
+
+// CurrentExporter returns the exporter of the ended spans, or nil if there is none
+func CurrentExporter() Exporter {
+	exporterMu.RLock()
+	defer exporterMu.RUnlock()
+	return exporter
+}
+
+// NewStdoutExporter returns an exporter which writes each span as a JSON object on a single line to the given writer
+// (e.g. os.Stdout)
+func NewStdoutExporter(w io.Writer) Exporter {
+	return &stdoutExporter{w: w}
+}
+
+type stdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (e *stdoutExporter) ExportSpan(span SpanData) {
+	js, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(js, '\n'))
+}
+
+// InMemoryExporter an exporter which keeps all the spans in memory, to verify them in tests
+type InMemoryExporter struct {
+	mu    sync.RWMutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter returns a new InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{
+		spans: []SpanData{},
+	}
+}
+
+// ExportSpan keeps the given span
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns a copy of all the exported spans, in the order in which they ended
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	spans := make([]SpanData, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Reset removes all the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = []SpanData{}
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-common/httpsupport/responsewriter"
+
+	"github.com/goadesign/goa"
+)
+
+// Middleware returns a goa middleware which starts a server span per request, as a child of the span
+// received in the `traceparent` header (if any). The span is named after the controller and the action
+// handling the request, and it is stored in the request context, so the log entries include its trace
+// and span IDs and the outgoing requests propagate it.
+func Middleware() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			name := fmt.Sprintf("%s.%s", goa.ContextController(ctx), goa.ContextAction(ctx))
+			ctx, span := StartSpan(Extract(ctx, req.Header), name, SpanKindServer)
+			defer span.End()
+			setRequestAttributes(span, req)
+			err := h(ctx, rw, req)
+			status := responsewriter.GoaStatus(ctx, err)
+			setStatus(span, status)
+			span.SetError(err)
+			return err
+		}
+	}
+}
+
+// Handler returns a handler which starts a server span per request handled by the given handler (see Middleware).
+// The span is named after the method of the request.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx, span := StartSpan(Extract(req.Context(), req.Header), "HTTP "+req.Method, SpanKindServer)
+		defer span.End()
+		setRequestAttributes(span, req)
+		recorder := responsewriter.NewRecorder(rw)
+		h.ServeHTTP(recorder, req.WithContext(ctx))
+		setStatus(span, recorder.Status())
+	})
+}
+
+// Do starts a client span for the given outgoing request, as a child of the span stored in the given context,
+// propagates it in the `traceparent` header of the request and sends the request with the given function
+func Do(ctx context.Context, req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	_, span := StartSpan(ctx, "HTTP "+req.Method, SpanKindClient)
+	defer span.End()
+	setRequestAttributes(span, req)
+	span.SetAttribute("http.host", req.URL.Host)
+	span.SetAttribute("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+	sc := span.SpanContext()
+	req.Header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		req.Header.Set(TracestateHeader, sc.TraceState)
+	}
+	resp, err := do(req)
+	if err != nil {
+		span.SetError(err)
+		return resp, err
+	}
+	setStatus(span, resp.StatusCode)
+	return resp, nil
+}
+
+func setRequestAttributes(span *Span, req *http.Request) {
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.target", req.URL.Path)
+}
+
+func setStatus(span *Span, status int) {
+	span.SetAttribute("http.status_code", status)
+	if status >= http.StatusInternalServerError {
+		span.SetError(fmt.Errorf("%d %s", status, http.StatusText(status)))
+	}
+}
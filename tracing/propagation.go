@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TraceparentHeader the header which carries the trace and parent span IDs between the services
+	TraceparentHeader = "traceparent"
+	// TracestateHeader the header which carries the vendor-specific trace information between the services
+	TracestateHeader = "tracestate"
+)
+
+const sampledFlag = 0x01
+
+// ParseTraceparent parses the value of a `traceparent` header, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(value string) (SpanContext, error) {
+	sc := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent: '%s'", value)
+	}
+	version, err := hex.DecodeString(parts[0])
+	// version 0xff is forbidden, and version 00 does not allow any additional part
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent version: '%s'", value)
+	}
+	if strings.ToLower(value) != value {
+		return sc, fmt.Errorf("invalid traceparent: '%s'", value)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("invalid trace ID in traceparent: '%s'", value)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("invalid parent ID in traceparent: '%s'", value)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, fmt.Errorf("invalid trace flags in traceparent: '%s'", value)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent with zero IDs: '%s'", value)
+	}
+	sc.Sampled = flags[0]&sampledFlag == sampledFlag
+	return sc, nil
+}
+
+// Traceparent returns the value of the `traceparent` header for the span context
+func (sc SpanContext) Traceparent() string {
+	var flags byte
+	if sc.Sampled {
+		flags = sampledFlag
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// Inject sets the `traceparent` (and `tracestate`) headers with the span context stored in the given context, if any
+func Inject(ctx context.Context, header http.Header) {
+	sc, found := SpanContextFromContext(ctx)
+	if !found || !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// Extract returns a copy of the given context in which the span context received in the `traceparent`
+// (and `tracestate`) headers is stored, or the given context itself if the headers are missing or invalid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = strings.Join(header[http.CanonicalHeaderKey(TracestateHeader)], ",")
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID the ID of a trace, shared by all its spans
+type TraceID [16]byte
+
+// String returns the hex-encoded trace ID
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid returns true if the trace ID is not only made of zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID the ID of a span
+type SpanID [8]byte
+
+// String returns the hex-encoded span ID
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid returns true if the span ID is not only made of zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext the part of a span which is propagated to the other services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// TraceState the vendor-specific trace information received in the `tracestate` header, forwarded as-is
+	TraceState string
+}
+
+// IsValid returns true if the trace and span IDs are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind the kind of a span
+type SpanKind int
+
+const (
+	// SpanKindInternal a span for an internal operation
+	SpanKindInternal SpanKind = iota
+	// SpanKindServer a span for the handling of an incoming request
+	SpanKindServer
+	// SpanKindClient a span for an outgoing request
+	SpanKindClient
+)
+
+// String returns the name of the span kind
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// SpanData a snapshot of an ended span, as passed to the Exporter
+type SpanData struct {
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Span an operation in a trace
+type Span struct {
+	mu           sync.Mutex
+	name         string
+	kind         SpanKind
+	spanContext  SpanContext
+	parentSpanID SpanID
+	start        time.Time
+	attributes   map[string]interface{}
+	err          string
+	ended        bool
+}
+
+type contextKey int
+
+const (
+	// spanKey the context key used to store the current span
+	spanKey contextKey = iota + 1
+	// remoteSpanContextKey the context key used to store the span context received from another service
+	remoteSpanContextKey
+)
+
+// StartSpan starts a new span with the given name and kind, and returns a copy of the given context in which
+// this span is stored. The new span is a child of the span stored in the context, or of the remote span received
+// from another service (see Extract), or else the root span of a new trace.
+// The span must be ended with Span.End.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: map[string]interface{}{},
+	}
+	if parent, found := SpanContextFromContext(ctx); found {
+		s.spanContext = SpanContext{
+			TraceID:    parent.TraceID,
+			Sampled:    parent.Sampled,
+			TraceState: parent.TraceState,
+		}
+		s.parentSpanID = parent.SpanID
+	} else {
+		rand.Read(s.spanContext.TraceID[:])
+		s.spanContext.Sampled = true
+	}
+	rand.Read(s.spanContext.SpanID[:])
+	return context.WithValue(ctx, spanKey, s), s
+}
+
+// SpanFromContext returns the span stored in the given context, or nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey).(*Span)
+	return s
+}
+
+// SpanContextFromContext returns the context of the span stored in the given context, or else the span
+// context received from another service
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.SpanContext(), true
+	}
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, found := ctx.Value(remoteSpanContextKey).(SpanContext)
+	return sc, found
+}
+
+// ContextWithRemoteSpanContext returns a copy of the given context in which the given span context,
+// received from another service, is stored. It will be the parent of the next span started with this context.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextKey, sc)
+}
+
+// SpanContext returns the context of the span
+func (s *Span) SpanContext() SpanContext {
+	return s.spanContext
+}
+
+// SetAttribute sets an attribute of the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with the given error
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End ends the span and passes it to the exporter if it is sampled.
+// Calling End more than once has no effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		Name:       s.name,
+		Kind:       s.kind.String(),
+		TraceID:    s.spanContext.TraceID.String(),
+		SpanID:     s.spanContext.SpanID.String(),
+		Start:      s.start,
+		End:        time.Now(),
+		Attributes: make(map[string]interface{}, len(s.attributes)),
+		Error:      s.err,
+	}
+	if s.parentSpanID.IsValid() {
+		data.ParentSpanID = s.parentSpanID.String()
+	}
+	for k, v := range s.attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+	if e := CurrentExporter(); e != nil && s.spanContext.Sampled {
+		e.ExportSpan(data)
+	}
+}
@@ -0,0 +1,235 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-common/resource"
+	"github.com/fabric8-services/fabric8-common/tracing"
+
+	"github.com/goadesign/goa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("valid", func(t *testing.T) {
+		// when
+		sc, err := tracing.ParseTraceparent(traceparent)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+		assert.True(t, sc.Sampled)
+		assert.Equal(t, traceparent, sc.Traceparent())
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		// when
+		sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		// then
+		require.NoError(t, err)
+		assert.False(t, sc.Sampled)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"foo",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo",
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+		} {
+			_, err := tracing.ParseTraceparent(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestStartSpan(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("new trace", func(t *testing.T) {
+		// when
+		ctx, span := tracing.StartSpan(context.Background(), "root", tracing.SpanKindInternal)
+		// then
+		assert.True(t, span.SpanContext().IsValid())
+		assert.True(t, span.SpanContext().Sampled)
+		assert.Equal(t, span, tracing.SpanFromContext(ctx))
+	})
+
+	t.Run("child span", func(t *testing.T) {
+		// given
+		ctx, parent := tracing.StartSpan(context.Background(), "parent", tracing.SpanKindInternal)
+		// when
+		_, child := tracing.StartSpan(ctx, "child", tracing.SpanKindInternal)
+		// then
+		assert.Equal(t, parent.SpanContext().TraceID, child.SpanContext().TraceID)
+		assert.NotEqual(t, parent.SpanContext().SpanID, child.SpanContext().SpanID)
+	})
+
+	t.Run("child of remote span", func(t *testing.T) {
+		// given
+		header := http.Header{}
+		header.Set(tracing.TraceparentHeader, traceparent)
+		header.Set(tracing.TracestateHeader, "congo=t61rcWkgMzE")
+		ctx := tracing.Extract(context.Background(), header)
+		// when
+		ctx, span := tracing.StartSpan(ctx, "child", tracing.SpanKindServer)
+		// then
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+		assert.NotEqual(t, "00f067aa0ba902b7", span.SpanContext().SpanID.String())
+		out := http.Header{}
+		tracing.Inject(ctx, out)
+		assert.Equal(t, span.SpanContext().Traceparent(), out.Get(tracing.TraceparentHeader))
+		assert.Equal(t, "congo=t61rcWkgMzE", out.Get(tracing.TracestateHeader))
+	})
+
+	t.Run("no span to inject", func(t *testing.T) {
+		// when
+		out := http.Header{}
+		tracing.Inject(context.Background(), out)
+		// then
+		assert.Empty(t, out.Get(tracing.TraceparentHeader))
+	})
+}
+
+// the tests below replace the global exporter, so they must not run in parallel
+
+func TestExporters(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	defer tracing.SetExporter(tracing.CurrentExporter())
+
+	t.Run("in memory", func(t *testing.T) {
+		// given
+		exporter := tracing.NewInMemoryExporter()
+		tracing.SetExporter(exporter)
+		ctx, parent := tracing.StartSpan(context.Background(), "parent", tracing.SpanKindInternal)
+		_, child := tracing.StartSpan(ctx, "child", tracing.SpanKindClient)
+		child.SetAttribute("foo", "bar")
+		child.SetError(errors.New("failure"))
+		// when
+		child.End()
+		child.End()
+		parent.End()
+		// then
+		spans := exporter.Spans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, "client", spans[0].Kind)
+		assert.Equal(t, parent.SpanContext().SpanID.String(), spans[0].ParentSpanID)
+		assert.Equal(t, "bar", spans[0].Attributes["foo"])
+		assert.Equal(t, "failure", spans[0].Error)
+		assert.Equal(t, "parent", spans[1].Name)
+		assert.Empty(t, spans[1].ParentSpanID)
+		exporter.Reset()
+		assert.Empty(t, exporter.Spans())
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		// given
+		exporter := tracing.NewInMemoryExporter()
+		tracing.SetExporter(exporter)
+		header := http.Header{}
+		header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		_, span := tracing.StartSpan(tracing.Extract(context.Background(), header), "span", tracing.SpanKindServer)
+		// when
+		span.End()
+		// then
+		assert.Empty(t, exporter.Spans())
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		// given
+		buf := &bytes.Buffer{}
+		tracing.SetExporter(tracing.NewStdoutExporter(buf))
+		_, span := tracing.StartSpan(context.Background(), "span", tracing.SpanKindInternal)
+		// when
+		span.End()
+		// then
+		data := tracing.SpanData{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &data))
+		assert.Equal(t, "span", data.Name)
+		assert.Equal(t, span.SpanContext().TraceID.String(), data.TraceID)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	defer tracing.SetExporter(tracing.CurrentExporter())
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetExporter(exporter)
+
+	t.Run("goa", func(t *testing.T) {
+		// given
+		exporter.Reset()
+		service := goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		ctrl := service.NewController("foo")
+		req, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set(tracing.TraceparentHeader, traceparent)
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(ctrl.Context, rw, req, nil)
+		ctx = goa.WithAction(ctx, "show")
+		var spanCtx tracing.SpanContext
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			spanCtx, _ = tracing.SpanContextFromContext(ctx)
+			return service.Send(ctx, http.StatusNotFound, "not found")
+		}
+		// when
+		err := tracing.Middleware()(h)(ctx, rw, req)
+		// then
+		require.NoError(t, err)
+		spans := exporter.Spans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "foo.show", spans[0].Name)
+		assert.Equal(t, "server", spans[0].Kind)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].ParentSpanID)
+		assert.Equal(t, spanCtx.SpanID.String(), spans[0].SpanID)
+		assert.Equal(t, http.StatusNotFound, spans[0].Attributes["http.status_code"])
+		assert.Empty(t, spans[0].Error)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		// given
+		exporter.Reset()
+		h := tracing.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			// propagate the server span to an outgoing request
+			out, _ := http.NewRequest(http.MethodGet, "http://example.com/bar", nil)
+			tracing.Do(req.Context(), out, func(r *http.Request) (*http.Response, error) {
+				assert.NotEmpty(t, r.Header.Get(tracing.TraceparentHeader))
+				return nil, errors.New("unreachable")
+			})
+			rw.WriteHeader(http.StatusBadGateway)
+		}))
+		req, _ := http.NewRequest(http.MethodPost, "/foo", nil)
+		// when
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		// then
+		spans := exporter.Spans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "client", spans[0].Kind)
+		assert.Equal(t, "example.com", spans[0].Attributes["http.host"])
+		assert.Equal(t, "unreachable", spans[0].Error)
+		assert.Equal(t, "HTTP POST", spans[1].Name)
+		assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+		assert.Equal(t, http.StatusBadGateway, spans[1].Attributes["http.status_code"])
+		assert.Equal(t, "502 Bad Gateway", spans[1].Error)
+	})
+}